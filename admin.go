@@ -0,0 +1,21 @@
+package main
+
+import (
+	"net/http"
+
+	"gitea.rannes.dev/christian/chirpy/internal/auth"
+)
+
+// requireAdmin only lets users with is_admin set through. It must run
+// behind auth.Middleware.Required.
+func (cfg *apiConfig) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userId, _ := auth.UserIDFromContext(r.Context())
+		user, err := cfg.db.GetUserByID(r.Context(), userId)
+		if err != nil || !user.IsAdmin {
+			respondWithError(w, 403, "You must be an admin to do this")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"gitea.rannes.dev/christian/chirpy/internal/auth"
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handleBlockUser blocks a user and removes any follows between the two
+// accounts. The visibility rules themselves live in the chirp queries.
+func (cfg *apiConfig) handleBlockUser(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	if id == userId {
+		respondWithError(w, 400, "You can't block yourself")
+		return
+	}
+	if _, err := cfg.db.GetUserByID(r.Context(), id); err != nil {
+		respondWithError(w, 404, fmt.Sprintf("User with id %s does not exist", id))
+		return
+	}
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "There was an error blocking the user")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+	if _, err := qtx.BlockUser(r.Context(), database.BlockUserParams{BlockerID: userId, BlockedID: id}); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error blocking the user: %s", err))
+		return
+	}
+	if err := qtx.DeleteFollowsBetween(r.Context(), database.DeleteFollowsBetweenParams{UserA: userId, UserB: id}); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error blocking the user: %s", err))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, 500, "There was an error blocking the user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handleUnblockUser(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	n, err := cfg.db.UnblockUser(r.Context(), database.UnblockUserParams{BlockerID: userId, BlockedID: id})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error unblocking the user: %s", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, fmt.Sprintf("You have not blocked user %s", id))
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handleMuteUser(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	if id == userId {
+		respondWithError(w, 400, "You can't mute yourself")
+		return
+	}
+	if _, err := cfg.db.GetUserByID(r.Context(), id); err != nil {
+		respondWithError(w, 404, fmt.Sprintf("User with id %s does not exist", id))
+		return
+	}
+	if _, err := cfg.db.MuteUser(r.Context(), database.MuteUserParams{MuterID: userId, MutedID: id}); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error muting the user: %s", err))
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handleUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	n, err := cfg.db.UnmuteUser(r.Context(), database.UnmuteUserParams{MuterID: userId, MutedID: id})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error unmuting the user: %s", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, fmt.Sprintf("You have not muted user %s", id))
+		return
+	}
+	w.WriteHeader(204)
+}
@@ -1,12 +1,13 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"slices"
-	"strings"
 	"time"
 
 	"gitea.rannes.dev/christian/chirpy/internal/auth"
@@ -18,12 +19,29 @@ type cleanedMsg struct {
 	CleanedMsg string `json:"cleaned_body"`
 }
 
+const (
+	chirpKindChirp   = "chirp"
+	chirpKindRechirp = "rechirp"
+	chirpKindQuote   = "quote"
+)
+
 type chirpSelect struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Body       string     `json:"body"`
+	UserID     uuid.UUID  `json:"user_id"`
+	InReplyTo  *uuid.UUID `json:"in_reply_to"`
+	RootID     *uuid.UUID `json:"root_id"`
+	ReplyCount int64      `json:"reply_count"`
+	LikeCount  int64      `json:"like_count"`
+	LikedByMe  *bool      `json:"liked_by_me,omitempty"`
+	Kind       string     `json:"kind"`
+	Filtered   bool       `json:"filtered,omitempty"`
+	// RechirpOf and QuoteOf embed the original chirp. A quote whose
+	// original was deleted keeps kind "quote" but has no quote_of.
+	RechirpOf *chirpSelect `json:"rechirp_of,omitempty"`
+	QuoteOf   *chirpSelect `json:"quote_of,omitempty"`
 }
 
 func (cfg *apiConfig) handleGetChirp(w http.ResponseWriter, r *http.Request) {
@@ -31,95 +49,458 @@ func (cfg *apiConfig) handleGetChirp(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Print(err)
 		respondWithError(w, 400, "You must enter a valid UUID")
+		return
 	}
-	chirp, err := cfg.db.GetChirp(r.Context(), id)
+	chirp, err := cfg.db.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{ID: id, ViewerID: viewerID(r.Context())})
 	if err != nil {
 		respondWithError(w, 404, fmt.Sprintf("Chirp with id %s doesn not exist", id))
+		return
 	}
-	c := chirpSelect{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+	c, err := cfg.renderChirp(r.Context(), chirp)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching the chirp: %s", err))
+		return
 	}
 	writeResponse(w, 200, c)
 }
 
-func (cfg *apiConfig) handleGetChirpList(w http.ResponseWriter, r *http.Request) {
-	chirps, err := cfg.db.ListChirps(r.Context())
+func (cfg *apiConfig) handleDeleteChirp(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("chirpId"))
 	if err != nil {
-		respondWithError(w, 500, fmt.Sprintf("There was an error fetching chirls: %s", err))
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
 	}
-	if len(chirps) == 0 {
-		respondWithError(w, 404, "No chirps found")
+	chirp, err := cfg.db.GetChirp(r.Context(), id)
+	if err != nil {
+		respondWithError(w, 404, fmt.Sprintf("Chirp with id %s does not exist", id))
+		return
 	}
-	chirpList := []chirpSelect{}
-	for _, chirp := range chirps {
-		c := chirpSelect{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID,
-		}
-		chirpList = append(chirpList, c)
+	if chirp.UserID != userId {
+		respondWithError(w, 403, "You can only delete your own chirps")
+		return
 	}
-	writeChirpListResponse(w, 200, chirpList)
+	if _, err := cfg.db.DeleteChirp(r.Context(), id); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error deleting the chirp: %s", err))
+		return
+	}
+	w.WriteHeader(204)
 }
 
-func writeChirpListResponse(w http.ResponseWriter, status int, responseBody []chirpSelect) {
-	body, err := json.Marshal(responseBody)
+func (cfg *apiConfig) handleGetChirpList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.ListChirpsFilteredAscParams{}
+	if authorId := query.Get("author_id"); authorId != "" {
+		id, err := uuid.Parse(authorId)
+		if err != nil {
+			respondWithError(w, 400, "author_id must be a valid UUID")
+			return
+		}
+		filter.AuthorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	for param, dest := range map[string]*sql.NullTime{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondWithError(w, 400, fmt.Sprintf("%s must be an RFC 3339 timestamp", param))
+			return
+		}
+		*dest = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+
+	page, err := parsePageQuery(r)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %s", err)
-		w.WriteHeader(500)
+		respondWithError(w, 400, err.Error())
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	if status != 200 {
-		w.WriteHeader(status)
+	filter.ViewerID = viewerID(r.Context())
+	filter.RowLimit = page.rowLimit()
+
+	var list func(context.Context, database.ListChirpsFilteredAscParams) ([]database.Chirp, error)
+	switch query.Get("sort") {
+	case "", "asc":
+		list = cfg.db.ListChirpsFilteredAsc
+	case "desc":
+		list = func(ctx context.Context, filter database.ListChirpsFilteredAscParams) ([]database.Chirp, error) {
+			return cfg.db.ListChirpsFilteredDesc(ctx, database.ListChirpsFilteredDescParams(filter))
+		}
+	default:
+		respondWithError(w, 400, "sort must be asc or desc")
+		return
 	}
-	w.Write(body)
+	cfg.writeChirpPage(w, r, page, func(ctx context.Context, page pageQuery) ([]database.Chirp, error) {
+		filter.CursorCreatedAt = page.CursorCreatedAt
+		filter.CursorID = page.CursorID
+		return list(ctx, filter)
+	})
 }
 
 func (cfg *apiConfig) handleCreateChirp(w http.ResponseWriter, r *http.Request) {
 	type chirpInsert struct {
-		Body   string    `json:"body"`
-		UserID uuid.UUID `json:"user_id"`
+		Body      string     `json:"body"`
+		UserID    uuid.UUID  `json:"user_id"`
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
+		RechirpOf *uuid.UUID `json:"rechirp_of"`
+		QuoteOf   *uuid.UUID `json:"quote_of"`
 	}
-	token, err := auth.GetBearerToken(r.Header)
+	userId, _ := auth.UserIDFromContext(r.Context())
+	decoder := json.NewDecoder(r.Body)
+	payload := chirpInsert{}
+	err := decoder.Decode(&payload)
 	if err != nil {
-		respondWithError(w, 401, "Error getting token from header")
+		respondWithError(w, 500, "Error decoding message")
 		return
 	}
-	userId, err := auth.ValidateJWT(token, cfg.secret)
+	params := database.CreateChirpParams{UserID: userId, Kind: chirpKindChirp}
+	switch {
+	case payload.RechirpOf != nil:
+		if payload.Body != "" || payload.InReplyTo != nil || payload.QuoteOf != nil {
+			respondWithError(w, 400, "A rechirp can't have a body, a parent or a quote")
+			return
+		}
+		original, err := cfg.originalChirp(r.Context(), *payload.RechirpOf)
+		if err != nil {
+			respondWithError(w, 404, fmt.Sprintf("Chirp with id %s does not exist", *payload.RechirpOf))
+			return
+		}
+		params.Kind = chirpKindRechirp
+		params.RechirpOf = uuid.NullUUID{UUID: original.ID, Valid: true}
+	case payload.QuoteOf != nil:
+		original, err := cfg.originalChirp(r.Context(), *payload.QuoteOf)
+		if err != nil {
+			respondWithError(w, 404, fmt.Sprintf("Chirp with id %s does not exist", *payload.QuoteOf))
+			return
+		}
+		params.Kind = chirpKindQuote
+		params.QuoteOf = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+	if params.Kind != chirpKindRechirp {
+		cMsg, err := cfg.cleanChirpBody(payload.Body)
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		params.Body = cMsg
+	}
+	if payload.InReplyTo != nil {
+		parent, err := cfg.db.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{ID: *payload.InReplyTo, ViewerID: viewerID(r.Context())})
+		if err != nil {
+			respondWithError(w, 404, fmt.Sprintf("Chirp with id %s does not exist", *payload.InReplyTo))
+			return
+		}
+		params.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		params.RootID = parent.RootID
+		if !parent.RootID.Valid {
+			params.RootID = params.ParentID
+		}
+	}
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
 	if err != nil {
-		respondWithError(w, 401, fmt.Sprintf("invalid token: %v", err))
+		respondWithError(w, 500, "There was an error saving your chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+	newChirp, err := qtx.CreateChirp(r.Context(), params)
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "You have already rechirped this chirp")
 		return
 	}
-	decoder := json.NewDecoder(r.Body)
-	payload := chirpInsert{}
-	err = decoder.Decode(&payload)
 	if err != nil {
-		respondWithError(w, 500, "Error decoding message")
+		log.Printf("There was an error saving your chirp to the db: %s", err)
+		respondWithError(w, 500, "There was an error saving your chirp")
+		return
+	}
+	if err := indexChirp(r.Context(), qtx, newChirp); err != nil {
+		log.Printf("There was an error indexing chirp %s: %s", newChirp.ID, err)
+		respondWithError(w, 500, "There was an error saving your chirp")
 		return
 	}
-	if len(payload.Body) > 140 {
-		respondWithError(w, 400, "chirp too long")
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, 500, "There was an error saving your chirp")
 		return
 	}
-	cMsg := censorProfanity(payload.Body)
-	newChirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{Body: cMsg, UserID: userId})
+	c, err := cfg.renderChirp(r.Context(), newChirp)
 	if err != nil {
-		log.Printf("There was an error saving your chirp to the db: %s", err)
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching the chirp: %s", err))
+		return
+	}
+	writeResponse(w, 201, c)
+}
+
+func (cfg *apiConfig) handleEditChirp(w http.ResponseWriter, r *http.Request) {
+	type chirpEdit struct {
+		Body string `json:"body"`
+	}
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	payload := chirpEdit{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondWithError(w, 400, "Error decoding message")
+		return
+	}
+	cMsg, err := cfg.cleanChirpBody(payload.Body)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "There was an error editing your chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+	// The row stays locked until commit, so a concurrent edit waits and then
+	// records this edit's body as its revision instead of losing it.
+	chirp, err := qtx.GetChirpForUpdate(r.Context(), id)
+	if err != nil {
+		respondWithError(w, 404, fmt.Sprintf("Chirp with id %s does not exist", id))
+		return
+	}
+	if chirp.UserID != userId {
+		respondWithError(w, 403, "You can only edit your own chirps")
+		return
+	}
+	if chirp.Kind == chirpKindRechirp {
+		respondWithError(w, 400, "Rechirps can't be edited")
+		return
 	}
-	writeResponse(w, 201, chirpSelect{
-		ID:        newChirp.ID,
-		CreatedAt: newChirp.CreatedAt,
-		UpdatedAt: newChirp.UpdatedAt,
-		Body:      newChirp.Body,
-		UserID:    newChirp.UserID,
+	if time.Since(chirp.CreatedAt) > cfg.editWindow {
+		respondWithError(w, 403, "The edit window for this chirp has closed")
+		return
+	}
+	err = qtx.InsertChirpRevision(r.Context(), database.InsertChirpRevisionParams{
+		ChirpID:   chirp.ID,
+		Body:      chirp.Body,
+		CreatedAt: chirp.UpdatedAt,
 	})
+	if err != nil {
+		respondWithError(w, 500, "There was an error editing your chirp")
+		return
+	}
+	updated, err := qtx.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+		ID:   chirp.ID,
+		Body: cMsg,
+	})
+	if err != nil {
+		respondWithError(w, 500, "There was an error editing your chirp")
+		return
+	}
+	if err := indexChirp(r.Context(), qtx, updated); err != nil {
+		log.Printf("There was an error indexing chirp %s: %s", updated.ID, err)
+		respondWithError(w, 500, "There was an error editing your chirp")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, 500, "There was an error editing your chirp")
+		return
+	}
+	c, err := cfg.renderChirp(r.Context(), updated)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching the chirp: %s", err))
+		return
+	}
+	writeResponse(w, 200, c)
+}
+
+func (cfg *apiConfig) handleGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	type chirpRevision struct {
+		Body       string    `json:"body"`
+		CreatedAt  time.Time `json:"created_at"`
+		ReplacedAt time.Time `json:"replaced_at"`
+	}
+	id, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	if _, err := cfg.db.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{ID: id, ViewerID: viewerID(r.Context())}); err != nil {
+		respondWithError(w, 404, fmt.Sprintf("Chirp with id %s does not exist", id))
+		return
+	}
+	revisions, err := cfg.db.ListChirpRevisions(r.Context(), id)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching revisions: %s", err))
+		return
+	}
+	revisionList := []chirpRevision{}
+	for _, rev := range revisions {
+		revisionList = append(revisionList, chirpRevision{
+			Body:       rev.Body,
+			CreatedAt:  rev.CreatedAt,
+			ReplacedAt: rev.ReplacedAt,
+		})
+	}
+	writeResponse(w, 200, revisionList)
+}
+
+// indexChirp rebuilds the hashtag and mention links for chirp after it is
+// created or edited. Links are removed by ON DELETE CASCADE when a chirp is
+// deleted.
+func indexChirp(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := linkHashtags(ctx, q, chirp); err != nil {
+		return err
+	}
+	return linkMentions(ctx, q, chirp)
+}
+
+func toChirpSelect(chirp database.Chirp) chirpSelect {
+	c := chirpSelect{
+		ID:        chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body:      chirp.Body,
+		UserID:    chirp.UserID,
+		Kind:      chirp.Kind,
+	}
+	if chirp.ParentID.Valid {
+		c.InReplyTo = &chirp.ParentID.UUID
+	}
+	if chirp.RootID.Valid {
+		c.RootID = &chirp.RootID.UUID
+	}
+	return c
+}
+
+// decorateChirps converts chirps to their API shape and fills in the counts
+// that live in other tables with one batched query per count. When the
+// request is authenticated the viewer's own likes are filled in as well.
+func (cfg *apiConfig) decorateChirps(ctx context.Context, chirps []database.Chirp) ([]chirpSelect, error) {
+	rendered := make([]chirpSelect, len(chirps))
+	ids := make([]uuid.UUID, len(chirps))
+	index := map[uuid.UUID]int{}
+	for i, chirp := range chirps {
+		rendered[i] = toChirpSelect(chirp)
+		ids[i] = chirp.ID
+		index[chirp.ID] = i
+	}
+	if len(chirps) == 0 {
+		return rendered, nil
+	}
+	replyCounts, err := cfg.db.CountChirpReplies(ctx, database.CountChirpRepliesParams{
+		ChirpIds: ids,
+		ViewerID: viewerID(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, rc := range replyCounts {
+		rendered[index[rc.ChirpID]].ReplyCount = rc.ReplyCount
+	}
+	likeCounts, err := cfg.db.CountChirpLikes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, lc := range likeCounts {
+		rendered[index[lc.ChirpID]].LikeCount = lc.LikeCount
+	}
+	if viewerId, ok := auth.UserIDFromContext(ctx); ok {
+		liked, err := cfg.db.ListLikedChirpIDs(ctx, database.ListLikedChirpIDsParams{
+			UserID:   viewerId,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i := range rendered {
+			rendered[i].LikedByMe = new(bool)
+		}
+		for _, id := range liked {
+			*rendered[index[id]].LikedByMe = true
+		}
+	}
+	return rendered, nil
+}
+
+// renderChirps decorates chirps and embeds the originals of rechirps and
+// quotes. Originals are decorated but not embedded themselves, so a quote of
+// a quote shows one level.
+func (cfg *apiConfig) renderChirps(ctx context.Context, chirps []database.Chirp) ([]chirpSelect, error) {
+	rendered, err := cfg.decorateChirps(ctx, chirps)
+	if err != nil {
+		return nil, err
+	}
+	var originalIds []uuid.UUID
+	for _, chirp := range chirps {
+		if chirp.RechirpOf.Valid {
+			originalIds = append(originalIds, chirp.RechirpOf.UUID)
+		}
+		if chirp.QuoteOf.Valid {
+			originalIds = append(originalIds, chirp.QuoteOf.UUID)
+		}
+	}
+	if len(originalIds) == 0 {
+		return rendered, nil
+	}
+	originals, err := cfg.db.ListChirpsByIDs(ctx, database.ListChirpsByIDsParams{
+		Ids:      originalIds,
+		ViewerID: viewerID(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+	decorated, err := cfg.decorateChirps(ctx, originals)
+	if err != nil {
+		return nil, err
+	}
+	byId := map[uuid.UUID]*chirpSelect{}
+	for i := range decorated {
+		byId[decorated[i].ID] = &decorated[i]
+	}
+	for i, chirp := range chirps {
+		if chirp.RechirpOf.Valid {
+			rendered[i].RechirpOf = byId[chirp.RechirpOf.UUID]
+		}
+		if chirp.QuoteOf.Valid {
+			rendered[i].QuoteOf = byId[chirp.QuoteOf.UUID]
+		}
+	}
+	return rendered, nil
+}
+
+func (cfg *apiConfig) renderChirp(ctx context.Context, chirp database.Chirp) (chirpSelect, error) {
+	rendered, err := cfg.renderChirps(ctx, []database.Chirp{chirp})
+	if err != nil {
+		return chirpSelect{}, err
+	}
+	return rendered[0], nil
+}
+
+// originalChirp returns the chirp that a rechirp or quote of id should point
+// at. Rechirps are resolved to what they rechirp so they never chain.
+func (cfg *apiConfig) originalChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	chirp, err := cfg.db.GetVisibleChirp(ctx, database.GetVisibleChirpParams{ID: id, ViewerID: viewerID(ctx)})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if chirp.RechirpOf.Valid {
+		return cfg.db.GetVisibleChirp(ctx, database.GetVisibleChirpParams{ID: chirp.RechirpOf.UUID, ViewerID: viewerID(ctx)})
+	}
+	return chirp, nil
+}
+
+// viewerID is the authenticated caller, if any. The chirp queries use it to
+// hide chirps across blocks and from muted users.
+func viewerID(ctx context.Context) uuid.NullUUID {
+	id, ok := auth.UserIDFromContext(ctx)
+	return uuid.NullUUID{UUID: id, Valid: ok}
+}
+
+// cleanChirpBody checks the length limit and censors profanity.
+func (cfg *apiConfig) cleanChirpBody(body string) (string, error) {
+	if len(body) > 140 {
+		return "", errors.New("chirp too long")
+	}
+	return cfg.profanity.Censor(body), nil
 }
 
 func respondWithError(w http.ResponseWriter, status int, msg string) {
@@ -132,6 +513,27 @@ func respondWithError(w http.ResponseWriter, status int, msg string) {
 	writeResponse(w, status, respBody)
 }
 
+// respondWithTokenError maps the typed errors from auth.Validator to distinct
+// 401 messages so clients know whether to refresh or log in again.
+func respondWithTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrMissingToken):
+		respondWithError(w, 401, "Error getting token from header")
+	case errors.Is(err, auth.ErrTokenExpired):
+		respondWithError(w, 401, "Token has expired")
+	case errors.Is(err, auth.ErrTokenNotValidYet):
+		respondWithError(w, 401, "Token is not valid yet")
+	case errors.Is(err, auth.ErrWrongAudience):
+		respondWithError(w, 401, "Token was not issued for this service")
+	case errors.Is(err, auth.ErrWrongIssuer):
+		respondWithError(w, 401, "Token was not issued by chirpy")
+	case errors.Is(err, auth.ErrBadSignature):
+		respondWithError(w, 401, "Token signature is invalid")
+	default:
+		respondWithError(w, 401, "Token is malformed")
+	}
+}
+
 func writeResponse(w http.ResponseWriter, status int, responseBody interface{}) {
 	body, err := json.Marshal(responseBody)
 	if err != nil {
@@ -145,15 +547,3 @@ func writeResponse(w http.ResponseWriter, status int, responseBody interface{})
 	}
 	w.Write(body)
 }
-
-func censorProfanity(msg string) string {
-	profList := []string{"kerfuffle", "sharbert", "fornax"}
-	msgSlice := strings.Split(msg, " ")
-	for i, v := range msgSlice {
-		if slices.Contains(profList, strings.ToLower(v)) {
-			msgSlice = slices.Replace(msgSlice, i, i+1, "****")
-		}
-	}
-	censoredMsg := strings.Join(msgSlice, " ")
-	return censoredMsg
-}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"gitea.rannes.dev/christian/chirpy/internal/auth"
+	"github.com/google/uuid"
+)
+
+// Accounts with more chirps than this get their export built in the
+// background instead of streamed in the request.
+const exportSyncLimit = 1000
+
+const exportTTL = 1 * time.Hour
+
+type exportJob struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Status    string
+	CreatedAt time.Time
+	path      string
+}
+
+type exportStore struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*exportJob
+}
+
+func newExportStore() *exportStore {
+	return &exportStore{jobs: map[uuid.UUID]*exportJob{}}
+}
+
+// start returns the user's pending export if there is one, and otherwise
+// registers a new pending job and reports that it was created. Finished
+// jobs are never reused, since the data may have changed since they ran.
+func (s *exportStore) start(userID uuid.UUID) (job *exportJob, created bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.jobs {
+		if existing.UserID == userID && existing.Status == "pending" {
+			return existing, false
+		}
+	}
+	job = &exportJob{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    "pending",
+		CreatedAt: time.Now().UTC(),
+	}
+	s.jobs[job.ID] = job
+	return job, true
+}
+
+type jsonExportJob struct {
+	ID        uuid.UUID `json:"id"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (cfg *apiConfig) handleExportUser(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	count, err := cfg.db.CountUserChirps(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching chirps: %s", err))
+		return
+	}
+	if count <= exportSyncLimit {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="chirpy-export.zip"`)
+		if err := cfg.writeExport(r.Context(), w, userId); err != nil {
+			// Headers are already sent, all we can do is log and cut the archive short.
+			log.Printf("Error exporting user %s: %s", userId, err)
+		}
+		return
+	}
+
+	// Only one export per user is built at a time; asking again while it
+	// runs returns the pending job.
+	job, created := cfg.exports.start(userId)
+	if created {
+		go cfg.runExport(job)
+	}
+
+	w.Header().Set("Location", "/api/users/me/export/"+job.ID.String())
+	writeResponse(w, 202, jsonExportJob{ID: job.ID, Status: "pending", CreatedAt: job.CreatedAt})
+}
+
+func (cfg *apiConfig) handleGetExport(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("exportId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	cfg.exports.mu.Lock()
+	job, ok := cfg.exports.jobs[id]
+	var status string
+	if ok {
+		status = job.Status
+	}
+	cfg.exports.mu.Unlock()
+	if !ok || job.UserID != userId {
+		respondWithError(w, 404, fmt.Sprintf("Export with id %s does not exist", id))
+		return
+	}
+	switch status {
+	case "pending":
+		writeResponse(w, 202, jsonExportJob{ID: job.ID, Status: status, CreatedAt: job.CreatedAt})
+	case "failed":
+		respondWithError(w, 500, "There was an error generating your export")
+	default:
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="chirpy-export.zip"`)
+		http.ServeFile(w, r, job.path)
+	}
+}
+
+func (cfg *apiConfig) runExport(job *exportJob) {
+	status := "ready"
+	f, err := os.CreateTemp("", "chirpy-export-*.zip")
+	if err == nil {
+		err = cfg.writeExport(context.Background(), f, job.UserID)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		log.Printf("Error exporting user %s: %s", job.UserID, err)
+		status = "failed"
+	}
+
+	cfg.exports.mu.Lock()
+	job.Status = status
+	if f != nil {
+		job.path = f.Name()
+	}
+	cfg.exports.mu.Unlock()
+
+	time.AfterFunc(exportTTL, func() {
+		cfg.exports.mu.Lock()
+		delete(cfg.exports.jobs, job.ID)
+		cfg.exports.mu.Unlock()
+		if job.path != "" {
+			os.Remove(job.path)
+		}
+	})
+}
+
+// writeExport writes a ZIP archive with everything stored about userId:
+// the account (without the password hash), chirps and their earlier
+// revisions, likes, follows in both directions, blocks, mutes, muted words
+// and session metadata.
+func (cfg *apiConfig) writeExport(ctx context.Context, w io.Writer, userId uuid.UUID) error {
+	user, err := cfg.db.GetUserByID(ctx, userId)
+	if err != nil {
+		return err
+	}
+	archive := zip.NewWriter(w)
+
+	f, err := archive.Create("user.json")
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(f).Encode(toJsonUser(user))
+	if err != nil {
+		return err
+	}
+
+	chirps, err := cfg.db.ListUserChirps(ctx, userId)
+	if err != nil {
+		return err
+	}
+	f, err = archive.Create("chirps.ndjson")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, chirp := range chirps {
+		if err := enc.Encode(toChirpSelect(chirp)); err != nil {
+			return err
+		}
+	}
+
+	revisions, err := cfg.db.ListUserChirpRevisions(ctx, userId)
+	if err != nil {
+		return err
+	}
+	enc, err = createNDJSON(archive, "chirp_revisions.ndjson")
+	if err != nil {
+		return err
+	}
+	type exportRevision struct {
+		ID         uuid.UUID `json:"id"`
+		ChirpID    uuid.UUID `json:"chirp_id"`
+		Body       string    `json:"body"`
+		CreatedAt  time.Time `json:"created_at"`
+		ReplacedAt time.Time `json:"replaced_at"`
+	}
+	for _, rev := range revisions {
+		if err := enc.Encode(exportRevision(rev)); err != nil {
+			return err
+		}
+	}
+
+	likes, err := cfg.db.ListUserChirpLikes(ctx, userId)
+	if err != nil {
+		return err
+	}
+	enc, err = createNDJSON(archive, "likes.ndjson")
+	if err != nil {
+		return err
+	}
+	type exportLike struct {
+		ChirpID   uuid.UUID `json:"chirp_id"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	for _, like := range likes {
+		if err := enc.Encode(exportLike{ChirpID: like.ChirpID, CreatedAt: like.CreatedAt}); err != nil {
+			return err
+		}
+	}
+
+	follows, err := cfg.db.ListUserFollows(ctx, userId)
+	if err != nil {
+		return err
+	}
+	enc, err = createNDJSON(archive, "follows.ndjson")
+	if err != nil {
+		return err
+	}
+	type exportFollow struct {
+		FollowerID uuid.UUID `json:"follower_id"`
+		FolloweeID uuid.UUID `json:"followee_id"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+	for _, follow := range follows {
+		if err := enc.Encode(exportFollow(follow)); err != nil {
+			return err
+		}
+	}
+
+	blocks, err := cfg.db.ListUserBlocks(ctx, userId)
+	if err != nil {
+		return err
+	}
+	enc, err = createNDJSON(archive, "blocks.ndjson")
+	if err != nil {
+		return err
+	}
+	type exportBlock struct {
+		BlockedID uuid.UUID `json:"blocked_id"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	for _, block := range blocks {
+		if err := enc.Encode(exportBlock{BlockedID: block.BlockedID, CreatedAt: block.CreatedAt}); err != nil {
+			return err
+		}
+	}
+
+	mutes, err := cfg.db.ListUserMutes(ctx, userId)
+	if err != nil {
+		return err
+	}
+	enc, err = createNDJSON(archive, "mutes.ndjson")
+	if err != nil {
+		return err
+	}
+	type exportMute struct {
+		MutedID   uuid.UUID `json:"muted_id"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	for _, mute := range mutes {
+		if err := enc.Encode(exportMute{MutedID: mute.MutedID, CreatedAt: mute.CreatedAt}); err != nil {
+			return err
+		}
+	}
+
+	// Expired muted words stay stored until the user deletes them, so they are
+	// exported too.
+	words, err := cfg.db.ListAllMutedWords(ctx, userId)
+	if err != nil {
+		return err
+	}
+	enc, err = createNDJSON(archive, "muted_words.ndjson")
+	if err != nil {
+		return err
+	}
+	for _, word := range words {
+		if err := enc.Encode(toJsonMutedWord(word)); err != nil {
+			return err
+		}
+	}
+
+	sessions, err := cfg.db.ListUserSessionHistory(ctx, userId)
+	if err != nil {
+		return err
+	}
+	f, err = archive.Create("sessions.ndjson")
+	if err != nil {
+		return err
+	}
+	type exportSession struct {
+		SessionID  uuid.UUID  `json:"session_id"`
+		CreatedAt  time.Time  `json:"created_at"`
+		ExpiresAt  time.Time  `json:"expires_at"`
+		RevokedAt  *time.Time `json:"revoked_at"`
+		UserAgent  string     `json:"user_agent"`
+		IPAddress  string     `json:"ip_address"`
+		LastUsedAt time.Time  `json:"last_used_at"`
+		Label      string     `json:"label"`
+	}
+	enc = json.NewEncoder(f)
+	for _, s := range sessions {
+		row := exportSession{
+			SessionID:  s.FamilyID,
+			CreatedAt:  s.CreatedAt,
+			ExpiresAt:  s.ExpiresAt,
+			UserAgent:  s.UserAgent,
+			IPAddress:  s.IpAddress,
+			LastUsedAt: s.LastUsedAt,
+			Label:      s.Label,
+		}
+		if s.RevokedAt.Valid {
+			row.RevokedAt = &s.RevokedAt.Time
+		}
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func createNDJSON(archive *zip.Writer, name string) (*json.Encoder, error) {
+	f, err := archive.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return json.NewEncoder(f), nil
+}
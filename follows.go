@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitea.rannes.dev/christian/chirpy/internal/auth"
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// publicUser is what other users get to see about an account.
+type publicUser struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Handle         string     `json:"handle"`
+	FollowerCount  int64      `json:"follower_count"`
+	FollowingCount int64      `json:"following_count"`
+	FollowedAt     *time.Time `json:"followed_at,omitempty"`
+}
+
+type userPage struct {
+	Users      []publicUser `json:"users"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// fillFollowCounts sets the follower and following counts of users with a
+// single query.
+func (cfg *apiConfig) fillFollowCounts(ctx context.Context, users []publicUser) error {
+	ids := make([]uuid.UUID, len(users))
+	index := map[uuid.UUID]int{}
+	for i, user := range users {
+		ids[i] = user.ID
+		index[user.ID] = i
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	counts, err := cfg.db.CountUserFollows(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, c := range counts {
+		users[index[c.UserID]].FollowerCount = c.FollowerCount
+		users[index[c.UserID]].FollowingCount = c.FollowingCount
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	user, err := cfg.db.GetUserByID(r.Context(), id)
+	if err != nil {
+		respondWithError(w, 404, fmt.Sprintf("User with id %s does not exist", id))
+		return
+	}
+	users := []publicUser{{ID: user.ID, CreatedAt: user.CreatedAt, Handle: user.Handle.String}}
+	if err := cfg.fillFollowCounts(r.Context(), users); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching the user: %s", err))
+		return
+	}
+	writeResponse(w, 200, users[0])
+}
+
+func (cfg *apiConfig) handleFollowUser(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	if id == userId {
+		respondWithError(w, 400, "You can't follow yourself")
+		return
+	}
+	if _, err := cfg.db.GetUserByID(r.Context(), id); err != nil {
+		respondWithError(w, 404, fmt.Sprintf("User with id %s does not exist", id))
+		return
+	}
+	blocked, err := cfg.db.IsBlockedBetween(r.Context(), database.IsBlockedBetweenParams{UserA: userId, UserB: id})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error following the user: %s", err))
+		return
+	}
+	if blocked {
+		respondWithError(w, 403, "You can't follow this user")
+		return
+	}
+	_, err = cfg.db.FollowUser(r.Context(), database.FollowUserParams{FollowerID: userId, FolloweeID: id})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error following the user: %s", err))
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handleUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	n, err := cfg.db.UnfollowUser(r.Context(), database.UnfollowUserParams{FollowerID: userId, FolloweeID: id})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error unfollowing the user: %s", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, fmt.Sprintf("You are not following user %s", id))
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handleGetFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.handleFollowList(w, r, func(ctx context.Context, page pageQuery, id uuid.UUID) ([]publicUser, error) {
+		rows, err := cfg.db.ListFollowers(ctx, database.ListFollowersParams{
+			UserID:          id,
+			CursorCreatedAt: page.CursorCreatedAt,
+			CursorID:        page.CursorID,
+			ViewerID:        viewerID(ctx),
+			RowLimit:        page.rowLimit(),
+		})
+		users := make([]publicUser, len(rows))
+		for i, row := range rows {
+			users[i] = publicUser{ID: row.ID, CreatedAt: row.CreatedAt, Handle: row.Handle.String, FollowedAt: &row.FollowedAt}
+		}
+		return users, err
+	})
+}
+
+func (cfg *apiConfig) handleGetFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.handleFollowList(w, r, func(ctx context.Context, page pageQuery, id uuid.UUID) ([]publicUser, error) {
+		rows, err := cfg.db.ListFollowing(ctx, database.ListFollowingParams{
+			UserID:          id,
+			CursorCreatedAt: page.CursorCreatedAt,
+			CursorID:        page.CursorID,
+			ViewerID:        viewerID(ctx),
+			RowLimit:        page.rowLimit(),
+		})
+		users := make([]publicUser, len(rows))
+		for i, row := range rows {
+			users[i] = publicUser{ID: row.ID, CreatedAt: row.CreatedAt, Handle: row.Handle.String, FollowedAt: &row.FollowedAt}
+		}
+		return users, err
+	})
+}
+
+// handleFollowList pages through one side of a user's follow graph, newest
+// follow first. The cursor is the (followed_at, id) of the last user.
+func (cfg *apiConfig) handleFollowList(w http.ResponseWriter, r *http.Request, list func(context.Context, pageQuery, uuid.UUID) ([]publicUser, error)) {
+	id, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	page, err := parsePageQuery(r)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	if _, err := cfg.db.GetUserByID(r.Context(), id); err != nil {
+		respondWithError(w, 404, fmt.Sprintf("User with id %s does not exist", id))
+		return
+	}
+	// Like a blocked user's chirps, their social graph is not found for
+	// the other side of the block.
+	if viewerId, ok := auth.UserIDFromContext(r.Context()); ok {
+		blocked, err := cfg.db.IsBlockedBetween(r.Context(), database.IsBlockedBetweenParams{UserA: viewerId, UserB: id})
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("There was an error fetching users: %s", err))
+			return
+		}
+		if blocked {
+			respondWithError(w, 404, fmt.Sprintf("User with id %s does not exist", id))
+			return
+		}
+	}
+	users, err := list(r.Context(), page, id)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching users: %s", err))
+		return
+	}
+	resp := userPage{}
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+		last := users[len(users)-1]
+		resp.NextCursor = encodeCursor(*last.FollowedAt, last.ID)
+		setNextLink(w, r, resp.NextCursor, page.Limit)
+	}
+	if err := cfg.fillFollowCounts(r.Context(), users); err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching users: %s", err))
+		return
+	}
+	resp.Users = users
+	writeResponse(w, 200, resp)
+}
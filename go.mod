@@ -10,3 +10,5 @@ require (
 )
 
 require github.com/golang-jwt/jwt/v5 v5.2.1
+
+require golang.org/x/text v0.21.0
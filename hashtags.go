@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"gitea.rannes.dev/christian/chirpy/internal/chirptext"
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+)
+
+// linkHashtags replaces the hashtag links of chirp with the hashtags in its
+// current body.
+func linkHashtags(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := q.DeleteChirpHashtags(ctx, chirp.ID); err != nil {
+		return err
+	}
+	for _, tag := range chirptext.Hashtags(chirp.Body) {
+		hashtag, err := q.UpsertHashtag(ctx, tag)
+		if err != nil {
+			return err
+		}
+		err = q.LinkChirpHashtag(ctx, database.LinkChirpHashtagParams{
+			ChirpID:   chirp.ID,
+			HashtagID: hashtag.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handleGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag, ok := chirptext.NormalizeHashtag(r.PathValue("tag"))
+	if !ok {
+		respondWithError(w, 400, "You must enter a valid hashtag")
+		return
+	}
+	page, err := parsePageQuery(r)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	cfg.writeChirpPage(w, r, page, func(ctx context.Context, page pageQuery) ([]database.Chirp, error) {
+		return cfg.db.ListHashtagChirps(ctx, database.ListHashtagChirpsParams{
+			Tag:             tag,
+			CursorCreatedAt: page.CursorCreatedAt,
+			CursorID:        page.CursorID,
+			ViewerID:        viewerID(ctx),
+			RowLimit:        page.rowLimit(),
+		})
+	})
+}
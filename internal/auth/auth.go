@@ -36,7 +36,7 @@ func MakeJWT(userId uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
     return "", errors.New("Token expiration must be positive.")
   }
 	claims := jwt.RegisteredClaims{
-		Issuer:    "chirpy",
+		Issuer:    Issuer,
 		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
 		Subject:   userId.String(),
@@ -55,7 +55,10 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 		&jwt.RegisteredClaims{},
 		func(token *jwt.Token) (interface{}, error) {
 			return []byte(tokenSecret), nil
-		})
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+	)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -77,12 +80,16 @@ func GetBearerToken(headers http.Header) (string, error) {
   if authHeader == "" {
     return "", errors.New("No authorization header in request")
   }
-  if !strings.HasPrefix(authHeader, "Bearer"){
+  if authHeader == "Bearer" {
+    return "", ErrMissingToken
+  }
+  tokenString, ok := strings.CutPrefix(authHeader, "Bearer ")
+  if !ok {
     return "", errors.New("No token found in headers")
   }
-  tokenString := strings.Split(authHeader, " ")[1]
+  tokenString = strings.TrimSpace(tokenString)
   if tokenString == "" {
-    return "", errors.New("Token is empty")
+    return "", ErrMissingToken
   }
   return tokenString, nil
 }
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"encoding/hex"
+	"errors"
 	"net/http"
 	"testing"
 	"time"
@@ -144,6 +145,7 @@ func TestGetBearerToken(t *testing.T) {
 		headerValue   string
 		expectedToken string
 		expectError   bool
+		expectedErr   error
 	}{
 		{
 			name:          "Valid bearer token",
@@ -168,6 +170,21 @@ func TestGetBearerToken(t *testing.T) {
 			headerValue:   "Bearer ",
 			expectedToken: "",
 			expectError:   true,
+			expectedErr:   ErrMissingToken,
+		},
+		{
+			name:          "Bearer without space",
+			headerValue:   "Bearer",
+			expectedToken: "",
+			expectError:   true,
+			expectedErr:   ErrMissingToken,
+		},
+		{
+			name:          "Bearer with only whitespace",
+			headerValue:   "Bearer   ",
+			expectedToken: "",
+			expectError:   true,
+			expectedErr:   ErrMissingToken,
 		},
 	}
 
@@ -185,6 +202,9 @@ func TestGetBearerToken(t *testing.T) {
 			if !tc.expectError && err != nil {
 				t.Errorf("Expected no error but got: %v", err)
 			}
+			if tc.expectedErr != nil && !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error %v but got: %v", tc.expectedErr, err)
+			}
 
 			if token != tc.expectedToken {
 				t.Errorf("Expected token %q but got %q", tc.expectedToken, token)
@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Key is a single JWT signing or verification key. Retired keys only carry
+// the public half and are kept around so tokens they signed stay valid until
+// they expire.
+type Key struct {
+	ID        string
+	Algorithm string
+	private   crypto.Signer
+	public    crypto.PublicKey
+}
+
+// Keyring holds the active signing key plus any retired verification keys.
+type Keyring struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// JWK is the public part of a key as published on the JWKS endpoint.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewKey(kid string, signer crypto.Signer) (*Key, error) {
+	alg, err := algorithmFor(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: kid, Algorithm: alg, private: signer, public: signer.Public()}, nil
+}
+
+func NewVerificationKey(kid string, public crypto.PublicKey) (*Key, error) {
+	alg, err := algorithmFor(public)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: kid, Algorithm: alg, public: public}, nil
+}
+
+// GenerateKey creates a fresh Ed25519 signing key.
+func GenerateKey(kid string) (*Key, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewKey(kid, private)
+}
+
+func NewKeyring(active *Key, retired ...*Key) (*Keyring, error) {
+	if active == nil || active.private == nil {
+		return nil, errors.New("Active key must be able to sign")
+	}
+	k := &Keyring{active: active, keys: map[string]*Key{active.ID: active}}
+	for _, key := range retired {
+		if _, ok := k.keys[key.ID]; ok {
+			return nil, fmt.Errorf("Duplicate key id %q", key.ID)
+		}
+		k.keys[key.ID] = key
+	}
+	return k, nil
+}
+
+// LoadKeyring reads every .pem file in dir. The file name without extension
+// is used as the key id. Private keys must be PKCS#8 encoded, retired keys
+// may be stored as PKIX public keys only.
+func LoadKeyring(dir, activeKid string) (*Keyring, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	var active *Key
+	retired := []*Key{}
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseKeyPEM(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if kid == activeKid {
+			active = key
+			continue
+		}
+		retired = append(retired, key)
+	}
+	if active == nil {
+		return nil, fmt.Errorf("Active key %q not found in %s", activeKid, dir)
+	}
+	return NewKeyring(active, retired...)
+}
+
+func parseKeyPEM(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("No PEM block found")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("Unsupported private key type")
+		}
+		return NewKey(kid, signer)
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewVerificationKey(kid, parsed)
+	}
+	return nil, fmt.Errorf("Unsupported PEM block %q", block.Type)
+}
+
+func algorithmFor(public crypto.PublicKey) (string, error) {
+	switch public.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256.Alg(), nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA.Alg(), nil
+	}
+	return "", fmt.Errorf("Unsupported key type %T", public)
+}
+
+func (k *Keyring) MakeJWT(userId uuid.UUID, audience string, expiresIn time.Duration) (string, error) {
+	if expiresIn <= 0 {
+		return "", errors.New("Token expiration must be positive.")
+	}
+	claims := jwt.RegisteredClaims{
+		Issuer:    Issuer,
+		Audience:  jwt.ClaimStrings{audience},
+		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+		Subject:   userId.String(),
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(k.active.Algorithm), claims)
+	token.Header["kid"] = k.active.ID
+	return token.SignedString(k.active.private)
+}
+
+func (k *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// JWKS returns the public keys in the keyring, sorted by key id.
+func (k *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range k.keys {
+		set.Keys = append(set.Keys, key.jwk())
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func (key *Key) jwk() JWK {
+	enc := base64.RawURLEncoding
+	jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Algorithm}
+	switch pub := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = enc.EncodeToString(pub.N.Bytes())
+		jwk.E = enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = enc.EncodeToString(pub)
+	}
+	return jwk
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestValidator(t *testing.T, keys *Keyring) *Validator {
+	t.Helper()
+	v, err := NewValidator(keys, ValidatorConfig{
+		Algorithms: []string{"EdDSA", "RS256"},
+		Issuer:     Issuer,
+		Audience:   "chirpy-test",
+	})
+	if err != nil {
+		t.Fatalf("NewValidator failed: %v", err)
+	}
+	return v
+}
+
+func newRSAKey(t *testing.T, kid string) *Key {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	key, err := NewKey(kid, private)
+	if err != nil {
+		t.Fatalf("NewKey failed: %v", err)
+	}
+	return key
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	edKey, err := GenerateKey("ed")
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	tests := []struct {
+		name string
+		key  *Key
+		alg  string
+	}{
+		{name: "EdDSA", key: edKey, alg: "EdDSA"},
+		{name: "RS256", key: newRSAKey(t, "rsa"), alg: "RS256"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.key.Algorithm != tt.alg {
+				t.Errorf("Wrong algorithm. got = %v, want = %v", tt.key.Algorithm, tt.alg)
+			}
+			keys, err := NewKeyring(tt.key)
+			if err != nil {
+				t.Fatalf("NewKeyring failed: %v", err)
+			}
+			userId := uuid.New()
+			token, err := keys.MakeJWT(userId, "chirpy-test", time.Hour)
+			if err != nil {
+				t.Fatalf("MakeJWT failed: %v", err)
+			}
+			extractedId, err := newTestValidator(t, keys).ValidateJWT(token)
+			if err != nil {
+				t.Fatalf("ValidateJWT failed: %v", err)
+			}
+			if extractedId != userId {
+				t.Errorf("Got wrong user ID. Want %v, got %v", userId, extractedId)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldKey, _ := GenerateKey("old")
+	newKey, _ := GenerateKey("new")
+	userId := uuid.New()
+
+	oldKeys, _ := NewKeyring(oldKey)
+	token, err := oldKeys.MakeJWT(userId, "chirpy-test", time.Hour)
+	if err != nil {
+		t.Fatalf("MakeJWT failed: %v", err)
+	}
+
+	// The old key is retired but still verifies tokens it signed
+	rotated, err := NewKeyring(newKey, oldKey)
+	if err != nil {
+		t.Fatalf("NewKeyring failed: %v", err)
+	}
+	if _, err := newTestValidator(t, rotated).ValidateJWT(token); err != nil {
+		t.Errorf("Token signed by retired key should validate: %v", err)
+	}
+
+	// Once dropped from the keyring the token is rejected
+	dropped, _ := NewKeyring(newKey)
+	if _, err := newTestValidator(t, dropped).ValidateJWT(token); err == nil {
+		t.Error("Expected error for token signed by unknown key")
+	}
+}
+
+func TestKeyringRejectsHS256(t *testing.T) {
+	key, _ := GenerateKey("ed")
+	keys, _ := NewKeyring(key)
+	token, _ := MakeJWT(uuid.New(), "secret", time.Hour)
+	if _, err := newTestValidator(t, keys).ValidateJWT(token); err == nil {
+		t.Error("Expected error for HS256 token")
+	}
+}
+
+func TestNewKeyringRequiresSigner(t *testing.T) {
+	key, _ := GenerateKey("ed")
+	public, _ := NewVerificationKey("ed", key.public)
+	if _, err := NewKeyring(public); err == nil {
+		t.Error("Expected error for verification-only active key")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	edKey, _ := GenerateKey("b-ed")
+	rsaKey := newRSAKey(t, "a-rsa")
+	keys, _ := NewKeyring(edKey, rsaKey)
+
+	set := keys.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(set.Keys))
+	}
+	if set.Keys[0].Kid != "a-rsa" || set.Keys[0].Kty != "RSA" || set.Keys[0].N == "" || set.Keys[0].E != "AQAB" {
+		t.Errorf("Unexpected RSA JWK: %+v", set.Keys[0])
+	}
+	if set.Keys[1].Kid != "b-ed" || set.Keys[1].Kty != "OKP" || set.Keys[1].Crv != "Ed25519" || set.Keys[1].X == "" {
+		t.Errorf("Unexpected Ed25519 JWK: %+v", set.Keys[1])
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	writePEM := func(name, blockType string, der []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatalf("Failed to write key: %v", err)
+		}
+	}
+	active, _ := GenerateKey("2025-01")
+	activeDER, _ := x509.MarshalPKCS8PrivateKey(active.private)
+	writePEM("2025-01.pem", "PRIVATE KEY", activeDER)
+	retired, _ := GenerateKey("2024-06")
+	retiredDER, _ := x509.MarshalPKIXPublicKey(retired.public)
+	writePEM("2024-06.pem", "PUBLIC KEY", retiredDER)
+
+	keys, err := LoadKeyring(dir, "2025-01")
+	if err != nil {
+		t.Fatalf("LoadKeyring failed: %v", err)
+	}
+	if len(keys.JWKS().Keys) != 2 {
+		t.Errorf("Expected 2 keys in keyring")
+	}
+	token, _ := keys.MakeJWT(uuid.New(), "chirpy-test", time.Hour)
+	if _, err := newTestValidator(t, keys).ValidateJWT(token); err != nil {
+		t.Errorf("ValidateJWT failed: %v", err)
+	}
+
+	if _, err := LoadKeyring(dir, "2024-06"); err == nil {
+		t.Error("Expected error when active key has no private half")
+	}
+	if _, err := LoadKeyring(dir, "missing"); err == nil {
+		t.Error("Expected error for missing active key")
+	}
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var ErrMissingToken = errors.New("no bearer token in request")
+
+type contextKey int
+
+const (
+	userIDKey contextKey = iota
+	claimsKey
+)
+
+// Middleware authenticates requests with a bearer JWT and stores the user id
+// and claims in the request context.
+type Middleware struct {
+	validator *Validator
+	onError   func(w http.ResponseWriter, err error)
+}
+
+// NewMiddleware returns a Middleware that reports authentication failures
+// through onError. Errors are ErrMissingToken or one of the Validator errors.
+func NewMiddleware(validator *Validator, onError func(w http.ResponseWriter, err error)) *Middleware {
+	return &Middleware{validator: validator, onError: onError}
+}
+
+// Required rejects requests without a valid bearer token.
+func (m *Middleware) Required(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := GetBearerToken(r.Header)
+		if err != nil {
+			m.onError(w, ErrMissingToken)
+			return
+		}
+		m.serveAuthenticated(w, r, token, next)
+	})
+}
+
+// Optional lets anonymous requests through but still rejects requests that
+// present an invalid token, so a client never silently loses its identity.
+func (m *Middleware) Optional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := GetBearerToken(r.Header)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		m.serveAuthenticated(w, r, token, next)
+	})
+}
+
+func (m *Middleware) serveAuthenticated(w http.ResponseWriter, r *http.Request, token string, next http.Handler) {
+	claims, err := m.validator.Validate(token)
+	if err != nil {
+		m.onError(w, err)
+		return
+	}
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		m.onError(w, ErrMalformedToken)
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), userId, claims)))
+}
+
+func ContextWithUser(ctx context.Context, userId uuid.UUID, claims *jwt.RegisteredClaims) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, userId)
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// UserIDFromContext returns the authenticated user, if any.
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userId, ok := ctx.Value(userIDKey).(uuid.UUID)
+	return userId, ok
+}
+
+func ClaimsFromContext(ctx context.Context) (*jwt.RegisteredClaims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*jwt.RegisteredClaims)
+	return claims, ok
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMiddleware(t *testing.T) {
+	key, _ := GenerateKey("active")
+	keys, _ := NewKeyring(key)
+	validator := newTestValidator(t, keys)
+	userId := uuid.New()
+	validToken, _ := keys.MakeJWT(userId, "chirpy-test", time.Hour)
+	wrongAudience, _ := keys.MakeJWT(userId, "someone-else", time.Hour)
+
+	tests := []struct {
+		name       string
+		required   bool
+		header     string
+		wantStatus int
+		wantErr    error
+		wantUser   bool
+	}{
+		{name: "Required with valid token", required: true, header: "Bearer " + validToken, wantStatus: 200, wantUser: true},
+		{name: "Required without token", required: true, header: "", wantStatus: 401, wantErr: ErrMissingToken},
+		{name: "Required with bad token", required: true, header: "Bearer " + wrongAudience, wantStatus: 401, wantErr: ErrWrongAudience},
+		{name: "Optional with valid token", required: false, header: "Bearer " + validToken, wantStatus: 200, wantUser: true},
+		{name: "Optional without token", required: false, header: "", wantStatus: 200, wantUser: false},
+		{name: "Optional with bad token", required: false, header: "Bearer " + wrongAudience, wantStatus: 401, wantErr: ErrWrongAudience},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotErr error
+			m := NewMiddleware(validator, func(w http.ResponseWriter, err error) {
+				gotErr = err
+				w.WriteHeader(401)
+			})
+			var gotUser uuid.UUID
+			var hasUser bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser, hasUser = UserIDFromContext(r.Context())
+				if _, ok := ClaimsFromContext(r.Context()); ok != hasUser {
+					t.Error("Claims and user id should be set together")
+				}
+			})
+			handler := m.Optional(next)
+			if tc.required {
+				handler = m.Required(next)
+			}
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("Expected status %d but got %d", tc.wantStatus, rec.Code)
+			}
+			if !errors.Is(gotErr, tc.wantErr) {
+				t.Errorf("Expected error %v but got %v", tc.wantErr, gotErr)
+			}
+			if hasUser != tc.wantUser {
+				t.Errorf("Expected user in context = %v", tc.wantUser)
+			}
+			if tc.wantUser && gotUser != userId {
+				t.Errorf("Got wrong user ID. Want %v, got %v", userId, gotUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const Issuer = "chirpy"
+
+var (
+	ErrTokenExpired     = errors.New("token has expired")
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+	ErrWrongAudience    = errors.New("token has the wrong audience")
+	ErrWrongIssuer      = errors.New("token has the wrong issuer")
+	ErrBadSignature     = errors.New("token signature is invalid")
+	ErrMalformedToken   = errors.New("token is malformed")
+)
+
+// ValidatorConfig controls which tokens a Validator accepts.
+type ValidatorConfig struct {
+	// Algorithms lists the signing algorithms that are accepted, e.g. "EdDSA".
+	Algorithms []string
+	Issuer     string
+	Audience   string
+	// Leeway is the clock skew tolerated when checking exp, nbf and iat.
+	Leeway time.Duration
+}
+
+type Validator struct {
+	keys   *Keyring
+	config ValidatorConfig
+	parser *jwt.Parser
+}
+
+func NewValidator(keys *Keyring, config ValidatorConfig) (*Validator, error) {
+	if len(config.Algorithms) == 0 {
+		return nil, errors.New("At least one algorithm must be allowed")
+	}
+	if config.Issuer == "" || config.Audience == "" {
+		return nil, errors.New("Issuer and audience are required")
+	}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(config.Algorithms),
+		jwt.WithIssuer(config.Issuer),
+		jwt.WithAudience(config.Audience),
+		jwt.WithLeeway(config.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	return &Validator{keys: keys, config: config, parser: parser}, nil
+}
+
+// Validate checks the token and returns its claims. Errors are one of the
+// Err* values in this package so callers can tell failures apart.
+func (v *Validator) Validate(tokenString string) (*jwt.RegisteredClaims, error) {
+	token, err := v.parser.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, v.keys.keyFunc)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	if !ok || !token.Valid {
+		return nil, ErrMalformedToken
+	}
+	return claims, nil
+}
+
+func (v *Validator) ValidateJWT(tokenString string) (uuid.UUID, error) {
+	claims, err := v.Validate(tokenString)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: invalid user ID in token", ErrMalformedToken)
+	}
+	return userId, nil
+}
+
+func classifyError(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return ErrTokenNotValidYet
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return ErrWrongAudience
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return ErrWrongIssuer
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return ErrBadSignature
+	}
+	return ErrMalformedToken
+}
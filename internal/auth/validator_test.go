@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func signClaims(t *testing.T, key *Key, claims jwt.RegisteredClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.private)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestValidatorErrors(t *testing.T) {
+	key, _ := GenerateKey("active")
+	keys, _ := NewKeyring(key)
+	impostor, _ := GenerateKey("active")
+	now := time.Now().UTC()
+	valid := jwt.RegisteredClaims{
+		Issuer:    Issuer,
+		Audience:  jwt.ClaimStrings{"chirpy-test"},
+		Subject:   uuid.New().String(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+
+	tests := []struct {
+		name    string
+		key     *Key
+		modify  func(c *jwt.RegisteredClaims)
+		leeway  time.Duration
+		wantErr error
+	}{
+		{
+			name:    "Valid token",
+			key:     key,
+			modify:  func(c *jwt.RegisteredClaims) {},
+			wantErr: nil,
+		},
+		{
+			name: "Expired token",
+			key:  key,
+			modify: func(c *jwt.RegisteredClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+			},
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name: "Expired token within leeway",
+			key:  key,
+			modify: func(c *jwt.RegisteredClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+			},
+			leeway:  2 * time.Minute,
+			wantErr: nil,
+		},
+		{
+			name: "Missing expiry",
+			key:  key,
+			modify: func(c *jwt.RegisteredClaims) {
+				c.ExpiresAt = nil
+			},
+			wantErr: ErrMalformedToken,
+		},
+		{
+			name: "Wrong audience",
+			key:  key,
+			modify: func(c *jwt.RegisteredClaims) {
+				c.Audience = jwt.ClaimStrings{"someone-else"}
+			},
+			wantErr: ErrWrongAudience,
+		},
+		{
+			name: "Wrong issuer",
+			key:  key,
+			modify: func(c *jwt.RegisteredClaims) {
+				c.Issuer = "not-chirpy"
+			},
+			wantErr: ErrWrongIssuer,
+		},
+		{
+			name:    "Bad signature",
+			key:     impostor,
+			modify:  func(c *jwt.RegisteredClaims) {},
+			wantErr: ErrBadSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewValidator(keys, ValidatorConfig{
+				Algorithms: []string{"EdDSA"},
+				Issuer:     Issuer,
+				Audience:   "chirpy-test",
+				Leeway:     tt.leeway,
+			})
+			if err != nil {
+				t.Fatalf("NewValidator failed: %v", err)
+			}
+			claims := valid
+			tt.modify(&claims)
+			_, err = v.Validate(signClaims(t, tt.key, claims))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorPinsAlgorithm(t *testing.T) {
+	keys, _ := NewKeyring(newRSAKey(t, "rsa"))
+	v, _ := NewValidator(keys, ValidatorConfig{
+		Algorithms: []string{"EdDSA"},
+		Issuer:     Issuer,
+		Audience:   "chirpy-test",
+	})
+	token, _ := keys.MakeJWT(uuid.New(), "chirpy-test", time.Hour)
+	if _, err := v.Validate(token); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Expected ErrBadSignature for disallowed algorithm, got %v", err)
+	}
+}
+
+func TestNewValidatorRequiresConfig(t *testing.T) {
+	key, _ := GenerateKey("active")
+	keys, _ := NewKeyring(key)
+	if _, err := NewValidator(keys, ValidatorConfig{Issuer: Issuer, Audience: "a"}); err == nil {
+		t.Error("Expected error without algorithms")
+	}
+	if _, err := NewValidator(keys, ValidatorConfig{Algorithms: []string{"EdDSA"}, Issuer: Issuer}); err == nil {
+		t.Error("Expected error without audience")
+	}
+}
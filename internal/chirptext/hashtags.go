@@ -0,0 +1,62 @@
+// Package chirptext extracts structured references such as hashtags from
+// chirp bodies.
+package chirptext
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxHashtagLength = 50
+
+// Hashtags returns the normalized hashtags in body in order of first
+// appearance, without duplicates. A hashtag starts with # at the start of
+// the body or after a character that cannot be part of a word, and must
+// contain at least one letter, so "#1" and "a#b" are not hashtags.
+func Hashtags(body string) []string {
+	return extract(body, '#')
+}
+
+// NormalizeHashtag lower-cases tag and strips a leading #. It returns false
+// if tag is not a valid hashtag.
+func NormalizeHashtag(tag string) (string, bool) {
+	word := strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if !isValidWord(word) || strings.IndexFunc(word, func(r rune) bool { return !isWordRune(r) }) >= 0 {
+		return "", false
+	}
+	return word, true
+}
+
+func extract(body string, marker rune) []string {
+	found := []string{}
+	seen := map[string]bool{}
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != marker || (i > 0 && isWordRune(runes[i-1])) {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		word := strings.ToLower(string(runes[i+1 : j]))
+		i = j - 1
+		if !isValidWord(word) || seen[word] {
+			continue
+		}
+		seen[word] = true
+		found = append(found, word)
+	}
+	return found
+}
+
+func isValidWord(word string) bool {
+	if word == "" || len([]rune(word)) > maxHashtagLength {
+		return false
+	}
+	return strings.IndexFunc(word, unicode.IsLetter) >= 0
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
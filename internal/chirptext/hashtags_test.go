@@ -0,0 +1,58 @@
+package chirptext
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "No hashtags", body: "just a chirp", want: []string{}},
+		{name: "Single hashtag", body: "learning #golang today", want: []string{"golang"}},
+		{name: "Normalizes case and dedupes", body: "#Go #go #GO", want: []string{"go"}},
+		{name: "Stops at punctuation", body: "so good #chirpy!", want: []string{"chirpy"}},
+		{name: "Keeps order", body: "#b then #a", want: []string{"b", "a"}},
+		{name: "Underscores and digits", body: "#advent_of_code2024", want: []string{"advent_of_code2024"}},
+		{name: "Needs a letter", body: "we're #1", want: []string{}},
+		{name: "Not inside words", body: "email a#b or c#", want: []string{}},
+		{name: "Unicode", body: "#smørrebrød", want: []string{"smørrebrød"}},
+		{name: "Too long", body: "#" + strings.Repeat("a", 51), want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hashtags(tt.body)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Hashtags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	tests := []struct {
+		tag    string
+		want   string
+		wantOk bool
+	}{
+		{tag: "Golang", want: "golang", wantOk: true},
+		{tag: "#Golang", want: "golang", wantOk: true},
+		{tag: "go lang", wantOk: false},
+		{tag: "123", wantOk: false},
+		{tag: "", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, ok := NormalizeHashtag(tt.tag)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("NormalizeHashtag(%q) = %q, %v, want %q, %v", tt.tag, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
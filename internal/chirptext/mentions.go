@@ -0,0 +1,48 @@
+package chirptext
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	minHandleLength = 3
+	maxHandleLength = 15
+)
+
+var ErrInvalidHandle = errors.New("handle must be 3 to 15 characters of letters, digits and underscores, and contain a letter")
+
+// ValidateHandle checks the rules for user handles. Handles are compared
+// case-insensitively but stored as the user typed them.
+func ValidateHandle(handle string) error {
+	if len(handle) < minHandleLength || len(handle) > maxHandleLength {
+		return ErrInvalidHandle
+	}
+	hasLetter := false
+	for _, r := range handle {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			hasLetter = true
+		case r >= '0' && r <= '9', r == '_':
+		default:
+			return ErrInvalidHandle
+		}
+	}
+	if !hasLetter {
+		return ErrInvalidHandle
+	}
+	return nil
+}
+
+// Mentions returns the lower-cased handles mentioned with @ in body, in order
+// of first appearance and without duplicates. Words that are not valid
+// handles, and @ signs inside words such as email addresses, are ignored.
+func Mentions(body string) []string {
+	mentions := []string{}
+	for _, word := range extract(body, '@') {
+		if ValidateHandle(word) == nil {
+			mentions = append(mentions, strings.ToLower(word))
+		}
+	}
+	return mentions
+}
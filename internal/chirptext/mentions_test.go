@@ -0,0 +1,55 @@
+package chirptext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidateHandle(t *testing.T) {
+	tests := []struct {
+		handle  string
+		wantErr bool
+	}{
+		{handle: "chirpy", wantErr: false},
+		{handle: "Chirpy_Fan_42", wantErr: false},
+		{handle: "ab", wantErr: true},
+		{handle: "a_very_long_handle", wantErr: true},
+		{handle: "12345", wantErr: true},
+		{handle: "with space", wantErr: true},
+		{handle: "smørrebrød", wantErr: true},
+		{handle: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.handle, func(t *testing.T) {
+			err := ValidateHandle(tt.handle)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateHandle(%q) error = %v, wantErr %v", tt.handle, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "No mentions", body: "hello world", want: []string{}},
+		{name: "Single mention", body: "hi @Alice!", want: []string{"alice"}},
+		{name: "Dedupes case-insensitively", body: "@bob and @BOB", want: []string{"bob"}},
+		{name: "Ignores emails", body: "mail me at me@example.com", want: []string{}},
+		{name: "Ignores invalid handles", body: "@ab @123 @ok_handle", want: []string{"ok_handle"}},
+		{name: "Keeps order", body: "@zed, @amy", want: []string{"zed", "amy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Mentions(tt.body)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Mentions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
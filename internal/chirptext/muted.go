@@ -0,0 +1,80 @@
+package chirptext
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Kinds of muted terms.
+const (
+	MuteWord    = "word"
+	MutePhrase  = "phrase"
+	MuteHashtag = "hashtag"
+)
+
+const maxMutedPhraseLength = 100
+
+var ErrInvalidMutedTerm = errors.New("muted term must be a word, a phrase of up to 100 characters or a valid hashtag")
+
+// MutedTerm is something a user doesn't want to see in chirps.
+type MutedTerm struct {
+	Kind  string
+	Value string
+}
+
+// NormalizeMutedTerm returns the stored form of value for kind: lower-cased,
+// with runs of whitespace collapsed and hashtags without their #.
+func NormalizeMutedTerm(kind, value string) (string, error) {
+	value = strings.Join(strings.Fields(strings.ToLower(value)), " ")
+	switch kind {
+	case MuteWord:
+		if !isValidWord(value) || strings.IndexFunc(value, func(r rune) bool { return !isWordRune(r) }) >= 0 {
+			return "", ErrInvalidMutedTerm
+		}
+		return value, nil
+	case MutePhrase:
+		if len(words(value)) == 0 || len([]rune(value)) > maxMutedPhraseLength {
+			return "", ErrInvalidMutedTerm
+		}
+		return value, nil
+	case MuteHashtag:
+		tag, ok := NormalizeHashtag(value)
+		if !ok {
+			return "", ErrInvalidMutedTerm
+		}
+		return tag, nil
+	}
+	return "", ErrInvalidMutedTerm
+}
+
+// Matches reports whether body contains the term. Words and phrases match
+// whole words case-insensitively, ignoring punctuation between them, so the
+// phrase "spoiler alert" matches "SPOILER... alert!" but not "spoilers alert".
+// A hashtag term only matches the hashtag, not the plain word.
+func (t MutedTerm) Matches(body string) bool {
+	switch t.Kind {
+	case MuteWord, MutePhrase:
+		needle := words(t.Value)
+		if len(needle) == 0 {
+			return false
+		}
+		haystack := words(body)
+		for i := 0; i+len(needle) <= len(haystack); i++ {
+			if slices.Equal(haystack[i:i+len(needle)], needle) {
+				return true
+			}
+		}
+		return false
+	case MuteHashtag:
+		return slices.Contains(Hashtags(body), t.Value)
+	}
+	return false
+}
+
+// words splits s into lower-cased runs of word characters.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
@@ -0,0 +1,59 @@
+package chirptext
+
+import "testing"
+
+func TestNormalizeMutedTerm(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Word", kind: MuteWord, value: " Spoilers ", want: "spoilers"},
+		{name: "Word with space", kind: MuteWord, value: "two words", wantErr: true},
+		{name: "Phrase", kind: MutePhrase, value: "Spoiler   ALERT", want: "spoiler alert"},
+		{name: "Punctuation only phrase", kind: MutePhrase, value: "!!!", wantErr: true},
+		{name: "Hashtag", kind: MuteHashtag, value: "#GameOfThrones", want: "gameofthrones"},
+		{name: "Invalid hashtag", kind: MuteHashtag, value: "#123", wantErr: true},
+		{name: "Unknown kind", kind: "emoji", value: "x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeMutedTerm(tt.kind, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeMutedTerm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeMutedTerm() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMutedTermMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		term MutedTerm
+		body string
+		want bool
+	}{
+		{name: "Word", term: MutedTerm{MuteWord, "spoilers"}, body: "No SPOILERS please", want: true},
+		{name: "Word inside another word", term: MutedTerm{MuteWord, "cat"}, body: "concatenate", want: false},
+		{name: "Word as hashtag", term: MutedTerm{MuteWord, "spoilers"}, body: "#spoilers ahead", want: true},
+		{name: "Phrase", term: MutedTerm{MutePhrase, "spoiler alert"}, body: "SPOILER... alert!", want: true},
+		{name: "Phrase out of order", term: MutedTerm{MutePhrase, "spoiler alert"}, body: "alert: spoiler", want: false},
+		{name: "Phrase with longer word", term: MutedTerm{MutePhrase, "spoiler alert"}, body: "spoilers alert", want: false},
+		{name: "Hashtag", term: MutedTerm{MuteHashtag, "got"}, body: "watching #GoT", want: true},
+		{name: "Hashtag as plain word", term: MutedTerm{MuteHashtag, "got"}, body: "I got it", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.term.Matches(tt.body); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
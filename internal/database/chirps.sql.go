@@ -7,25 +7,102 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countChirpReplies = `-- name: CountChirpReplies :many
+WITH RECURSIVE replies AS (
+  SELECT chirps.id, chirps.parent_id AS chirp_id FROM chirps
+  WHERE chirps.parent_id = ANY($1::uuid[])
+    AND NOT hidden_from_viewer(chirps.user_id, $2)
+  UNION ALL
+  SELECT chirps.id, replies.chirp_id FROM chirps
+  JOIN replies ON chirps.parent_id = replies.id
+  WHERE NOT hidden_from_viewer(chirps.user_id, $2)
+)
+SELECT chirp_id::uuid AS chirp_id, COUNT(*) AS reply_count FROM replies
+GROUP BY chirp_id
+`
+
+type CountChirpRepliesParams struct {
+	ChirpIds []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+type CountChirpRepliesRow struct {
+	ChirpID    uuid.UUID
+	ReplyCount int64
+}
+
+// Counts the replies below each chirp that ListChirpReplies returns to the
+// viewer, so reply_count matches the thread.
+func (q *Queries) CountChirpReplies(ctx context.Context, arg CountChirpRepliesParams) ([]CountChirpRepliesRow, error) {
+	rows, err := q.db.QueryContext(ctx, countChirpReplies, pq.Array(arg.ChirpIds), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountChirpRepliesRow
+	for rows.Next() {
+		var i CountChirpRepliesRow
+		if err := rows.Scan(&i.ChirpID, &i.ReplyCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countUserChirps = `-- name: CountUserChirps :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1
+`
+
+func (q *Queries) CountUserChirps(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUserChirps, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createChirp = `-- name: CreateChirp :one
 INSERT INTO
-  chirps (id, created_at, updated_at, body, user_id)
+  chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, kind, rechirp_of, quote_of)
 VALUES
-  (gen_random_uuid(), NOW(), NOW(), $1, $2)
-RETURNING id, created_at, updated_at, body, user_id
+  (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5, $6, $7)
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body      string
+	UserID    uuid.UUID
+	ParentID  uuid.NullUUID
+	RootID    uuid.NullUUID
+	Kind      string
+	RechirpOf uuid.NullUUID
+	QuoteOf   uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.ParentID,
+		arg.RootID,
+		arg.Kind,
+		arg.RechirpOf,
+		arg.QuoteOf,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -33,12 +110,31 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.ParentID,
+		&i.RootID,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.QuoteOf,
 	)
 	return i, err
 }
 
+const deleteChirp = `-- name: DeleteChirp :execrows
+DELETE FROM chirps
+WHERE id = $1
+`
+
+func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteChirp, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM chirps
 WHERE id = $1
 `
 
@@ -51,12 +147,249 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.ParentID,
+		&i.RootID,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.QuoteOf,
 	)
 	return i, err
 }
 
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM chirps
+WHERE id = $1
+FOR UPDATE
+`
+
+// GetChirpForUpdate locks the chirp until the transaction ends, so
+// concurrent edits are applied one after the other.
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentID,
+		&i.RootID,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.QuoteOf,
+	)
+	return i, err
+}
+
+const getVisibleChirp = `-- name: GetVisibleChirp :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM chirps
+WHERE chirps.id = $1
+  AND NOT blocked_between(chirps.user_id, $2)
+`
+
+type GetVisibleChirpParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetVisibleChirp(ctx context.Context, arg GetVisibleChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getVisibleChirp, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentID,
+		&i.RootID,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.QuoteOf,
+	)
+	return i, err
+}
+
+const insertChirpRevision = `-- name: InsertChirpRevision :exec
+INSERT INTO
+  chirp_revisions (id, chirp_id, body, created_at, replaced_at)
+VALUES
+  (gen_random_uuid(), $1, $2, $3, NOW())
+`
+
+type InsertChirpRevisionParams struct {
+	ChirpID   uuid.UUID
+	Body      string
+	CreatedAt time.Time
+}
+
+func (q *Queries) InsertChirpRevision(ctx context.Context, arg InsertChirpRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, insertChirpRevision, arg.ChirpID, arg.Body, arg.CreatedAt)
+	return err
+}
+
+const listChirpAncestors = `-- name: ListChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+  SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_id, chirps.root_id, chirps.kind, chirps.rechirp_of, chirps.quote_of, 1 AS depth FROM chirps
+  WHERE chirps.id = (SELECT c.parent_id FROM chirps c WHERE c.id = $1)
+  UNION ALL
+  SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_id, chirps.root_id, chirps.kind, chirps.rechirp_of, chirps.quote_of, ancestors.depth + 1 FROM chirps
+  JOIN ancestors ON chirps.id = ancestors.parent_id
+)
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM ancestors
+WHERE NOT blocked_between(ancestors.user_id, $2)
+ORDER BY depth DESC
+`
+
+type ListChirpAncestorsParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) ListChirpAncestors(ctx context.Context, arg ListChirpAncestorsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpAncestors, arg.ID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.QuoteOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpReplies = `-- name: ListChirpReplies :many
+WITH RECURSIVE replies AS (
+  SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_id, chirps.root_id, chirps.kind, chirps.rechirp_of, chirps.quote_of FROM chirps
+  WHERE chirps.parent_id = $1
+    AND NOT hidden_from_viewer(chirps.user_id, $2)
+  UNION ALL
+  SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_id, chirps.root_id, chirps.kind, chirps.rechirp_of, chirps.quote_of FROM chirps
+  JOIN replies ON chirps.parent_id = replies.id
+  WHERE NOT hidden_from_viewer(chirps.user_id, $2)
+)
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM replies
+WHERE $3::timestamp IS NULL
+  OR (created_at, id) > ($3, $4::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type ListChirpRepliesParams struct {
+	ChirpID         uuid.UUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	RowLimit        int32
+}
+
+// The recursion stops at chirps hidden from the viewer, so replies below
+// them are left out rather than shown without their parent.
+func (q *Queries) ListChirpReplies(ctx context.Context, arg ListChirpRepliesParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpReplies,
+		arg.ChirpID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.QuoteOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpRevisions = `-- name: ListChirpRevisions :many
+SELECT id, chirp_id, body, created_at, replaced_at FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY replaced_at
+`
+
+func (q *Queries) ListChirpRevisions(ctx context.Context, chirpID uuid.UUID) ([]ChirpRevision, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpRevisions, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpRevision
+	for rows.Next() {
+		var i ChirpRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ReplacedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listChirps = `-- name: ListChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM chirps
 ORDER BY created_at
 `
 
@@ -75,6 +408,123 @@ func (q *Queries) ListChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.QuoteOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsByIDs = `-- name: ListChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM chirps
+WHERE chirps.id = ANY($1::uuid[])
+  AND NOT blocked_between(chirps.user_id, $2)
+`
+
+type ListChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) ListChirpsByIDs(ctx context.Context, arg ListChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.QuoteOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsFilteredAsc = `-- name: ListChirpsFilteredAsc :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM chirps
+WHERE ($1::uuid IS NULL OR user_id = $1)
+  AND ($2::timestamp IS NULL OR created_at >= $2)
+  AND ($3::timestamp IS NULL OR created_at < $3)
+  AND ($4::timestamp IS NULL
+    OR (created_at, id) > ($4, $5::uuid))
+  AND NOT hidden_from_viewer(chirps.user_id, $6)
+ORDER BY created_at ASC, id ASC
+LIMIT $7
+`
+
+type ListChirpsFilteredAscParams struct {
+	AuthorID        uuid.NullUUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	RowLimit        int32
+}
+
+func (q *Queries) ListChirpsFilteredAsc(ctx context.Context, arg ListChirpsFilteredAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsFilteredAsc,
+		arg.AuthorID,
+		arg.Since,
+		arg.Until,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.QuoteOf,
 		); err != nil {
 			return nil, err
 		}
@@ -88,3 +538,257 @@ func (q *Queries) ListChirps(ctx context.Context) ([]Chirp, error) {
 	}
 	return items, nil
 }
+
+const listChirpsFilteredDesc = `-- name: ListChirpsFilteredDesc :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM chirps
+WHERE ($1::uuid IS NULL OR user_id = $1)
+  AND ($2::timestamp IS NULL OR created_at >= $2)
+  AND ($3::timestamp IS NULL OR created_at < $3)
+  AND ($4::timestamp IS NULL
+    OR (created_at, id) < ($4, $5::uuid))
+  AND NOT hidden_from_viewer(chirps.user_id, $6)
+ORDER BY created_at DESC, id DESC
+LIMIT $7
+`
+
+type ListChirpsFilteredDescParams struct {
+	AuthorID        uuid.NullUUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	RowLimit        int32
+}
+
+func (q *Queries) ListChirpsFilteredDesc(ctx context.Context, arg ListChirpsFilteredDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsFilteredDesc,
+		arg.AuthorID,
+		arg.Since,
+		arg.Until,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.QuoteOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserChirpRevisions = `-- name: ListUserChirpRevisions :many
+SELECT chirp_revisions.id, chirp_revisions.chirp_id, chirp_revisions.body, chirp_revisions.created_at, chirp_revisions.replaced_at FROM chirp_revisions
+JOIN chirps ON chirps.id = chirp_revisions.chirp_id
+WHERE chirps.user_id = $1
+ORDER BY chirp_revisions.chirp_id, chirp_revisions.replaced_at
+`
+
+func (q *Queries) ListUserChirpRevisions(ctx context.Context, userID uuid.UUID) ([]ChirpRevision, error) {
+	rows, err := q.db.QueryContext(ctx, listUserChirpRevisions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpRevision
+	for rows.Next() {
+		var i ChirpRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ReplacedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserChirps = `-- name: ListUserChirps :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM chirps
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListUserChirps(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listUserChirps, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.QuoteOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of, rank FROM (
+  SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_id, chirps.root_id, chirps.kind, chirps.rechirp_of, chirps.quote_of, ts_rank(chirps.search_vector, query)::real AS rank
+  FROM chirps, to_tsquery('english', $1) query
+  WHERE chirps.search_vector @@ query
+    AND NOT hidden_from_viewer(chirps.user_id, $2)
+) results
+WHERE $3::real IS NULL
+  OR (rank, created_at, id) < ($3, $4::timestamp, $5::uuid)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT $6
+`
+
+type SearchChirpsParams struct {
+	Query           string
+	ViewerID        uuid.NullUUID
+	CursorRank      sql.NullFloat64
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	RowLimit        int32
+}
+
+type SearchChirpsRow struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Body         string
+	UserID       uuid.UUID
+	SearchVector interface{}
+	ParentID     uuid.NullUUID
+	RootID       uuid.NullUUID
+	Kind         string
+	RechirpOf    uuid.NullUUID
+	QuoteOf      uuid.NullUUID
+	Rank         float32
+}
+
+// Results are ordered by relevance and paged by (rank, created_at, id).
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.ViewerID,
+		arg.CursorRank,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.Kind,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of
+`
+
+type UpdateChirpBodyParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentID,
+		&i.RootID,
+		&i.Kind,
+		&i.RechirpOf,
+		&i.QuoteOf,
+	)
+	return i, err
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func createTestUser(t *testing.T, q *Queries, email string) User {
+	t.Helper()
+	user, err := q.CreateUser(context.Background(), CreateUserParams{Email: email, HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// createTestChirp creates a chirp by user, as a reply when parent is set.
+func createTestChirp(t *testing.T, q *Queries, user User, parent *Chirp) Chirp {
+	t.Helper()
+	params := CreateChirpParams{Body: "chirp", UserID: user.ID, Kind: "chirp"}
+	if parent != nil {
+		root := parent.ID
+		if parent.RootID.Valid {
+			root = parent.RootID.UUID
+		}
+		params.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		params.RootID = uuid.NullUUID{UUID: root, Valid: true}
+	}
+	chirp, err := q.CreateChirp(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chirp
+}
+
+func TestDeleteChirpKeepsRepliesInThread(t *testing.T) {
+	q := openTestDB(t)
+	ctx := context.Background()
+	user := createTestUser(t, q, "thread@example.com")
+	root := createTestChirp(t, q, user, nil)
+	middle := createTestChirp(t, q, user, &root)
+	child := createTestChirp(t, q, user, &middle)
+	grandchild := createTestChirp(t, q, user, &child)
+
+	if _, err := q.DeleteChirp(ctx, middle.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	replies, err := q.ListChirpReplies(ctx, ListChirpRepliesParams{ChirpID: root.ID, RowLimit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uuid.UUID{child.ID, grandchild.ID}
+	if len(replies) != len(want) {
+		t.Fatalf("Expected %d replies, got %d", len(want), len(replies))
+	}
+	for i, id := range want {
+		if replies[i].ID != id {
+			t.Errorf("Reply %d: got %s, want %s", i, replies[i].ID, id)
+		}
+	}
+	if replies[0].ParentID.UUID != root.ID {
+		t.Errorf("Expected the deleted chirp's reply to move up to the root, got parent %v", replies[0].ParentID)
+	}
+}
+
+func TestHiddenReplyHidesItsSubtree(t *testing.T) {
+	q := openTestDB(t)
+	ctx := context.Background()
+	author := createTestUser(t, q, "author@example.com")
+	blocked := createTestUser(t, q, "blocked@example.com")
+	viewer := createTestUser(t, q, "viewer@example.com")
+	root := createTestChirp(t, q, author, nil)
+	hidden := createTestChirp(t, q, blocked, &root)
+	createTestChirp(t, q, author, &hidden)
+	if _, err := q.BlockUser(ctx, BlockUserParams{BlockerID: viewer.ID, BlockedID: blocked.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		viewer uuid.NullUUID
+		want   int
+	}{
+		{name: "Anonymous", want: 2},
+		{name: "Blocking viewer", viewer: uuid.NullUUID{UUID: viewer.ID, Valid: true}, want: 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			replies, err := q.ListChirpReplies(ctx, ListChirpRepliesParams{ChirpID: root.ID, ViewerID: tc.viewer, RowLimit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(replies) != tc.want {
+				t.Errorf("Expected %d replies, got %d", tc.want, len(replies))
+			}
+			counts, err := q.CountChirpReplies(ctx, CountChirpRepliesParams{ChirpIds: []uuid.UUID{root.ID}, ViewerID: tc.viewer})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var count int64
+			for _, c := range counts {
+				if c.ChirpID == root.ID {
+					count = c.ReplyCount
+				}
+			}
+			if count != int64(tc.want) {
+				t.Errorf("Expected reply count %d, got %d", tc.want, count)
+			}
+		})
+	}
+}
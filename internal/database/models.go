@@ -5,26 +5,118 @@
 package database
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Chirp struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Body         string
+	UserID       uuid.UUID
+	SearchVector interface{}
+	ParentID     uuid.NullUUID
+	RootID       uuid.NullUUID
+	Kind         string
+	RechirpOf    uuid.NullUUID
+	QuoteOf      uuid.NullUUID
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpLike struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type DeletedUser struct {
+	ID               uuid.UUID
+	DeletedAt        time.Time
+	UserHash         sql.NullString
+	AccountCreatedAt time.Time
+	ChirpCount       int64
+	SessionCount     int64
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
 	ID        uuid.UUID
+	Tag       string
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	Body      string
+}
+
+type MutedWord struct {
+	ID        uuid.UUID
 	UserID    uuid.UUID
+	Kind      string
+	Value     string
+	Action    string
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
 }
 
-type RefreshToken struct {
-	Token     string
+type ProfaneWord struct {
+	Word      string
 	CreatedAt time.Time
-	UpdatedAt time.Time
+}
+
+type RefreshToken struct {
+	Token      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+	FamilyID   uuid.UUID
+	ReplacedBy sql.NullString
+	UserAgent  string
+	IpAddress  string
+	LastUsedAt time.Time
+	Label      string
+}
+
+type TimelineEntry struct {
 	UserID    uuid.UUID
-	ExpiresAt time.Time
-	RevokedAt time.Time
+	ChirpID   uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type TrendingHashtag struct {
+	WindowName    string
+	Rank          int32
+	Tag           string
+	RecentCount   int64
+	PreviousCount int64
+	Score         float64
+	ComputedAt    time.Time
 }
 
 type User struct {
@@ -33,4 +125,18 @@ type User struct {
 	UpdatedAt      time.Time
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
+	IsAdmin        bool
+}
+
+type UserBlock struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserMute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
 }
@@ -0,0 +1,185 @@
+// Package profanity censors words from a configurable list in chirp bodies.
+// Matching sees through case, Unicode compatibility forms, accents,
+// punctuation and common character substitutions, while the censored text
+// keeps the original whitespace and punctuation around each word.
+package profanity
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Replacement is what a censored word is replaced with.
+const Replacement = "****"
+
+// Filter censors text.
+type Filter interface {
+	Censor(text string) string
+}
+
+// Reloader is a filter whose word list can be reloaded at runtime.
+type Reloader interface {
+	Reload(ctx context.Context) (int, error)
+}
+
+// Source provides the list of words to censor.
+type Source interface {
+	Words(ctx context.Context) ([]string, error)
+}
+
+// substitutions maps characters commonly used in place of letters.
+var substitutions = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+	'+': 't',
+}
+
+var folder = cases.Fold()
+
+// Normalize returns the form words are compared in: NFKC, case folded,
+// without accents, with substitutions mapped to letters and everything that
+// is not a letter removed, so "K3RFÜFFLE" becomes "kerfuffle".
+func Normalize(word string) string {
+	word = folder.String(norm.NFKC.String(word))
+	var b strings.Builder
+	for _, r := range norm.NFD.String(word) {
+		if sub, ok := substitutions[r]; ok {
+			r = sub
+		}
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// WordFilter censors whole words that are in its list.
+type WordFilter struct {
+	words map[string]bool
+}
+
+// NewWordFilter returns a filter for words. Words are normalized, so the
+// list doesn't have to spell out variants.
+func NewWordFilter(words []string) *WordFilter {
+	f := &WordFilter{words: map[string]bool{}}
+	for _, word := range words {
+		if n := Normalize(word); n != "" {
+			f.words[n] = true
+		}
+	}
+	return f
+}
+
+// Len is the number of distinct normalized words in the filter.
+func (f *WordFilter) Len() int {
+	return len(f.words)
+}
+
+// Censor replaces every listed word in text with Replacement. Text is split
+// into runs of letters, digits and substitution characters; everything else
+// is copied unchanged. Substitution characters at the edges of a run are
+// only part of the word if that makes it match, so "kerfuffle!" becomes
+// "****!" and "$harbert" becomes "****".
+func (f *WordFilter) Censor(text string) string {
+	if len(f.words) == 0 {
+		return text
+	}
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		b.WriteString(f.censorWord(runes[i:j]))
+		i = j
+	}
+	return b.String()
+}
+
+// censorWord tries the word with its edges trimmed down to the first and
+// last letter or digit, widest first.
+func (f *WordFilter) censorWord(word []rune) string {
+	first := 0
+	for first < len(word)-1 && !isCoreRune(word[first]) {
+		first++
+	}
+	last := len(word) - 1
+	for last > first && !isCoreRune(word[last]) {
+		last--
+	}
+	for start := 0; start <= first; start++ {
+		for end := len(word); end > last; end-- {
+			if f.words[Normalize(string(word[start:end]))] {
+				return string(word[:start]) + Replacement + string(word[end:])
+			}
+		}
+	}
+	return string(word)
+}
+
+func isCoreRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isWordRune(r rune) bool {
+	_, sub := substitutions[r]
+	return sub || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// Reloadable is a WordFilter whose list can be reloaded from its Source
+// while it is in use.
+type Reloadable struct {
+	source  Source
+	current atomic.Pointer[WordFilter]
+}
+
+// NewReloadable loads the word list from source.
+func NewReloadable(ctx context.Context, source Source) (*Reloadable, error) {
+	r := &Reloadable{source: source}
+	if _, err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload replaces the word list with the current contents of the source and
+// returns the number of words loaded. On error the old list stays in use.
+func (r *Reloadable) Reload(ctx context.Context) (int, error) {
+	words, err := r.source.Words(ctx)
+	if err != nil {
+		return 0, err
+	}
+	f := NewWordFilter(words)
+	r.current.Store(f)
+	return f.Len(), nil
+}
+
+var (
+	_ Filter   = (*WordFilter)(nil)
+	_ Filter   = (*Reloadable)(nil)
+	_ Reloader = (*Reloadable)(nil)
+)
+
+func (r *Reloadable) Censor(text string) string {
+	return r.current.Load().Censor(text)
+}
@@ -0,0 +1,93 @@
+package profanity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "Kerfuffle", want: "kerfuffle"},
+		{word: "K3RFUFFL3", want: "kerfuffle"},
+		{word: "$h@rb3rt", want: "sharbert"},
+		{word: "ｆｏｒｎａｘ", want: "fornax"},
+		{word: "fórnäx", want: "fornax"},
+		{word: "f.o.r.n.a.x", want: "fornax"},
+		{word: "STRASSE", want: "strasse"},
+		{word: "...", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := Normalize(tt.word); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordFilterCensor(t *testing.T) {
+	f := NewWordFilter([]string{"kerfuffle", "Sharbert", "fornax"})
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "Plain", text: "what a kerfuffle today", want: "what a **** today"},
+		{name: "Case", text: "KERFUFFLE", want: "****"},
+		{name: "Trailing punctuation", text: "such a kerfuffle!", want: "such a ****!"},
+		{name: "Surrounding punctuation", text: "(fornax), right?", want: "(****), right?"},
+		{name: "Leetspeak", text: "k3rfuffl3 and $harbert", want: "**** and ****"},
+		{name: "Full width", text: "ｆｏｒｎａｘ", want: "****"},
+		{name: "Accents", text: "fórnäx", want: "****"},
+		{name: "Keeps whitespace", text: "a  fornax\tb\nc", want: "a  ****\tb\nc"},
+		{name: "Not inside other words", text: "kerfuffled fornaxes", want: "kerfuffled fornaxes"},
+		{name: "Clean text", text: "I hear Mastodon is better than Chirpy.", want: "I hear Mastodon is better than Chirpy."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.Censor(tt.text); got != tt.want {
+				t.Errorf("Censor(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReloadable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("# banned\nkerfuffle\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewReloadable(context.Background(), FileSource(path))
+	if err != nil {
+		t.Fatalf("NewReloadable() error = %v", err)
+	}
+	if got := f.Censor("kerfuffle fornax"); got != "**** fornax" {
+		t.Errorf("Censor() = %q before reload", got)
+	}
+
+	if err := os.WriteFile(path, []byte("kerfuffle\nfornax\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	n, err := f.Reload(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("Reload() = %d, %v, want 2 words", n, err)
+	}
+	if got := f.Censor("kerfuffle fornax"); got != "**** ****" {
+		t.Errorf("Censor() = %q after reload", got)
+	}
+
+	os.Remove(path)
+	if _, err := f.Reload(context.Background()); err == nil {
+		t.Error("Reload() should fail when the file is gone")
+	}
+	if got := f.Censor("fornax"); got != "****" {
+		t.Errorf("Censor() = %q, want the old list after a failed reload", got)
+	}
+}
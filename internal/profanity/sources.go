@@ -0,0 +1,38 @@
+package profanity
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+)
+
+// Words is a fixed word list.
+type Words []string
+
+func (w Words) Words(ctx context.Context) ([]string, error) {
+	return w, nil
+}
+
+// FileSource reads a word list from a file with one word per line. Blank
+// lines and lines starting with # are ignored. The file is read again on
+// every reload.
+type FileSource string
+
+func (path FileSource) Words(ctx context.Context) ([]string, error) {
+	f, err := os.Open(string(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	words := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
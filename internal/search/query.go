@@ -0,0 +1,78 @@
+// Package search turns user supplied search strings into PostgreSQL
+// tsquery expressions.
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var ErrEmptyQuery = errors.New("search query has no searchable terms")
+
+// ParseQuery converts q into the to_tsquery syntax. Terms are ANDed together,
+// "quoted phrases" must appear in order, a trailing * makes a prefix match and
+// a leading - excludes a term. Everything that is not a letter or digit is
+// dropped so the result is always a valid tsquery.
+func ParseQuery(q string) (string, error) {
+	parts := []string{}
+	for i, segment := range strings.Split(q, `"`) {
+		// Odd segments are inside quotes.
+		if i%2 == 1 {
+			if phrase := parsePhrase(segment); phrase != "" {
+				parts = append(parts, phrase)
+			}
+			continue
+		}
+		for _, field := range strings.Fields(segment) {
+			if term := parseTerm(field); term != "" {
+				parts = append(parts, term)
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return "", ErrEmptyQuery
+	}
+	return strings.Join(parts, " & "), nil
+}
+
+func parsePhrase(phrase string) string {
+	words := []string{}
+	for _, field := range strings.Fields(phrase) {
+		if word := sanitize(field); word != "" {
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	if len(words) == 1 {
+		return words[0]
+	}
+	return "(" + strings.Join(words, " <-> ") + ")"
+}
+
+func parseTerm(field string) string {
+	negate := strings.HasPrefix(field, "-")
+	prefix := strings.HasSuffix(field, "*")
+	word := sanitize(field)
+	if word == "" {
+		return ""
+	}
+	if prefix {
+		word += ":*"
+	}
+	if negate {
+		word = "!" + word
+	}
+	return word
+}
+
+func sanitize(word string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, word))
+}
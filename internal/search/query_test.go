@@ -0,0 +1,36 @@
+package search
+
+import "testing"
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Single word", query: "chirpy", want: "chirpy"},
+		{name: "Multiple words", query: "hello  World", want: "hello & world"},
+		{name: "Prefix", query: "kerf*", want: "kerf:*"},
+		{name: "Phrase", query: `"good morning" coffee`, want: "(good <-> morning) & coffee"},
+		{name: "Single word phrase", query: `"hello"`, want: "hello"},
+		{name: "Negation", query: "coffee -tea", want: "coffee & !tea"},
+		{name: "Strips tsquery syntax", query: "a&b | !c:*", want: "ab & c:*"},
+		{name: "Unicode letters", query: "smørrebrød", want: "smørrebrød"},
+		{name: "Unterminated quote", query: `"good morning`, want: "(good <-> morning)"},
+		{name: "Empty", query: "   ", wantErr: true},
+		{name: "Only punctuation", query: `!! "" &`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Package trending scores hashtags by how fast their usage is growing.
+package trending
+
+import (
+	"math"
+	"sort"
+)
+
+// Count is the number of uses of a tag in the current window and in the
+// window of the same length before it.
+type Count struct {
+	Tag      string
+	Recent   int64
+	Previous int64
+	Score    float64
+}
+
+// Score favors growth over raw volume. A tag used at a steady rate scores
+// around zero however popular it is, while a tag that jumps from nothing to a
+// handful of uses scores high. Dividing by the square root of the previous
+// count treats usage as Poisson noise, so large tags need a proportionally
+// larger jump to trend.
+func Score(recent, previous int64) float64 {
+	return float64(recent-previous) / math.Sqrt(float64(previous)+1)
+}
+
+// Rank scores counts and returns the top limit tags, highest score first.
+// Ties are broken by recent volume and then alphabetically so the order is
+// stable between runs.
+func Rank(counts []Count, limit int) []Count {
+	ranked := make([]Count, len(counts))
+	for i, c := range counts {
+		c.Score = Score(c.Recent, c.Previous)
+		ranked[i] = c
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		if ranked[i].Recent != ranked[j].Recent {
+			return ranked[i].Recent > ranked[j].Recent
+		}
+		return ranked[i].Tag < ranked[j].Tag
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
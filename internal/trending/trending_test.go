@@ -0,0 +1,37 @@
+package trending
+
+import "testing"
+
+func TestScoreFavorsGrowth(t *testing.T) {
+	steady := Score(1000, 1000)
+	rising := Score(20, 2)
+	if rising <= steady {
+		t.Errorf("Rising tag should outscore steady tag: rising = %v, steady = %v", rising, steady)
+	}
+	if Score(5, 10) >= 0 {
+		t.Error("Declining tag should have a negative score")
+	}
+}
+
+func TestRank(t *testing.T) {
+	counts := []Count{
+		{Tag: "steady", Recent: 500, Previous: 500},
+		{Tag: "new", Recent: 10, Previous: 0},
+		{Tag: "growing", Recent: 300, Previous: 100},
+		{Tag: "b", Recent: 3, Previous: 3},
+		{Tag: "a", Recent: 3, Previous: 3},
+	}
+	got := Rank(counts, 4)
+	want := []string{"growing", "new", "steady", "a"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d tags, got %d", len(want), len(got))
+	}
+	for i, tag := range want {
+		if got[i].Tag != tag {
+			t.Errorf("Rank %d: got %q, want %q", i, got[i].Tag, tag)
+		}
+	}
+	if counts[0].Score != 0 {
+		t.Error("Rank should not modify its input")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"gitea.rannes.dev/christian/chirpy/internal/auth"
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handleLikeChirp likes a chirp on behalf of the caller. Liking a chirp twice
+// is not an error, the response is the chirp with its current like count.
+func (cfg *apiConfig) handleLikeChirp(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	chirp, err := cfg.db.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{ID: id, ViewerID: viewerID(r.Context())})
+	if err != nil {
+		respondWithError(w, 404, fmt.Sprintf("Chirp with id %s does not exist", id))
+		return
+	}
+	_, err = cfg.db.LikeChirp(r.Context(), database.LikeChirpParams{ChirpID: id, UserID: userId})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error liking the chirp: %s", err))
+		return
+	}
+	c, err := cfg.renderChirp(r.Context(), chirp)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching the chirp: %s", err))
+		return
+	}
+	writeResponse(w, 200, c)
+}
+
+func (cfg *apiConfig) handleUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	n, err := cfg.db.UnlikeChirp(r.Context(), database.UnlikeChirpParams{ChirpID: id, UserID: userId})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error unliking the chirp: %s", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, fmt.Sprintf("You have not liked chirp %s", id))
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// handleGetUserLikes lists the chirps a user has liked, most recently liked
+// first.
+func (cfg *apiConfig) handleGetUserLikes(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	page, err := parsePageQuery(r)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	if _, err := cfg.db.GetUserByID(r.Context(), userId); err != nil {
+		respondWithError(w, 404, fmt.Sprintf("User with id %s does not exist", userId))
+		return
+	}
+	// The cursor is the like's timestamp, not the chirp's.
+	cfg.writePagedChirps(w, r, page.Limit, func(ctx context.Context, cursor string) ([]pagedChirp, error) {
+		next, err := page.after(cursor)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := cfg.db.ListUserLikes(ctx, database.ListUserLikesParams{
+			UserID:          userId,
+			CursorCreatedAt: next.CursorCreatedAt,
+			CursorID:        next.CursorID,
+			ViewerID:        viewerID(ctx),
+			RowLimit:        next.rowLimit(),
+		})
+		paged := make([]pagedChirp, len(rows))
+		for i, row := range rows {
+			paged[i] = pagedChirp{Chirp: row.Chirp, Cursor: encodeCursor(row.LikedAt, row.Chirp.ID)}
+		}
+		return paged, err
+	})
+}
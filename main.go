@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"sync/atomic"
 	"time"
 
+	"gitea.rannes.dev/christian/chirpy/internal/auth"
 	"gitea.rannes.dev/christian/chirpy/internal/database"
+	"gitea.rannes.dev/christian/chirpy/internal/profanity"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -16,10 +21,17 @@ import (
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
+	conn           *sql.DB
 	platform       string
-	secret         string
+	keys           *auth.Keyring
+	jwtAudience    string
 	tokenExpiry    time.Duration
 	resetExpiry    time.Duration
+	exports        *exportStore
+	editWindow     time.Duration
+	profanity      profanity.Filter
+	profanityList  profanity.Reloader
+	tombstoneKey   []byte
 }
 
 const PORT = "8080"
@@ -36,32 +48,165 @@ func main() {
 		log.Fatalf("There was an error connecting to the database: %s", err)
 	}
 
+	platform := os.Getenv("PLATFORM")
+
+	keys, err := loadKeyring(platform)
+	if err != nil {
+		log.Fatalf("There was an error loading the JWT signing keys: %s", err)
+	}
+
+	jwtAudience := os.Getenv("JWT_AUDIENCE")
+	if jwtAudience == "" {
+		jwtAudience = "chirpy-api"
+	}
+	jwtLeeway := 30 * time.Second
+	if leeway := os.Getenv("JWT_LEEWAY"); leeway != "" {
+		jwtLeeway, err = time.ParseDuration(leeway)
+		if err != nil {
+			log.Fatalf("Invalid JWT_LEEWAY: %s", err)
+		}
+	}
+	validator, err := auth.NewValidator(keys, auth.ValidatorConfig{
+		Algorithms: []string{"EdDSA", "RS256"},
+		Issuer:     auth.Issuer,
+		Audience:   jwtAudience,
+		Leeway:     jwtLeeway,
+	})
+	if err != nil {
+		log.Fatalf("There was an error configuring JWT validation: %s", err)
+	}
+
+	tombstoneKey, err := loadTombstoneKey(platform)
+	if err != nil {
+		log.Fatalf("There was an error loading TOMBSTONE_KEY: %s", err)
+	}
+
+	editWindow := 15 * time.Minute
+	if window := os.Getenv("CHIRP_EDIT_WINDOW"); window != "" {
+		editWindow, err = time.ParseDuration(window)
+		if err != nil {
+			log.Fatalf("Invalid CHIRP_EDIT_WINDOW: %s", err)
+		}
+	}
+
+	dbQueries := database.New(db)
+	profanityFilter, err := profanity.NewReloadable(context.Background(), profanitySource(dbQueries))
+	if err != nil {
+		log.Fatalf("There was an error loading the profanity word list: %s", err)
+	}
+
 	mux := http.NewServeMux()
 	srv := http.Server{
 		Addr:    ":" + PORT,
 		Handler: mux,
 	}
 
-	dbQueries := database.New(db)
 	apiCfg := apiConfig{
 		fileserverHits: atomic.Int32{},
 		db:             dbQueries,
-		platform:       os.Getenv("PLATFORM"),
-		secret:         os.Getenv("SECRET"),
+		conn:           db,
+		platform:       platform,
+		keys:           keys,
+		jwtAudience:    jwtAudience,
 		tokenExpiry:    1 * time.Hour,
 		resetExpiry:    60 * 24 * time.Hour,
+		exports:        newExportStore(),
+		editWindow:     editWindow,
+		profanity:      profanityFilter,
+		profanityList:  profanityFilter,
+		tombstoneKey:   tombstoneKey,
 	}
 
+	authn := auth.NewMiddleware(validator, respondWithTokenError)
+
 	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app/", http.FileServer(http.Dir(".")))))
 	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
 	mux.HandleFunc("POST /admin/reset", apiCfg.handleResetUsers)
+	mux.Handle("POST /admin/profanity/reload", authn.Required(apiCfg.requireAdmin(http.HandlerFunc(apiCfg.handleReloadProfanity))))
 	mux.HandleFunc("GET /api/healthz", HandleHealthz)
+	mux.HandleFunc("GET /.well-known/jwks.json", apiCfg.handleJWKS)
 	mux.HandleFunc("POST /api/users", apiCfg.handleCreateUser)
+	mux.Handle("PUT /api/users", authn.Required(http.HandlerFunc(apiCfg.handleUpdateUser)))
+	mux.Handle("DELETE /api/users/me", authn.Required(http.HandlerFunc(apiCfg.handleDeleteUser)))
+	mux.Handle("GET /api/users/me/mentions", authn.Required(http.HandlerFunc(apiCfg.handleGetMentions)))
+	mux.Handle("GET /api/users/me/muted-words", authn.Required(http.HandlerFunc(apiCfg.handleListMutedWords)))
+	mux.Handle("POST /api/users/me/muted-words", authn.Required(http.HandlerFunc(apiCfg.handleCreateMutedWord)))
+	mux.Handle("DELETE /api/users/me/muted-words/{mutedWordId}", authn.Required(http.HandlerFunc(apiCfg.handleDeleteMutedWord)))
+	mux.Handle("GET /api/users/me/export", authn.Required(http.HandlerFunc(apiCfg.handleExportUser)))
+	mux.Handle("POST /api/users/me/export", authn.Required(http.HandlerFunc(apiCfg.handleExportUser)))
+	mux.Handle("GET /api/users/me/export/{exportId}", authn.Required(http.HandlerFunc(apiCfg.handleGetExport)))
+	mux.HandleFunc("GET /api/users/{userId}", apiCfg.handleGetUser)
+	mux.Handle("GET /api/users/{userId}/likes", authn.Optional(http.HandlerFunc(apiCfg.handleGetUserLikes)))
+	mux.Handle("POST /api/users/{userId}/follow", authn.Required(http.HandlerFunc(apiCfg.handleFollowUser)))
+	mux.Handle("DELETE /api/users/{userId}/follow", authn.Required(http.HandlerFunc(apiCfg.handleUnfollowUser)))
+	mux.Handle("POST /api/users/{userId}/block", authn.Required(http.HandlerFunc(apiCfg.handleBlockUser)))
+	mux.Handle("DELETE /api/users/{userId}/block", authn.Required(http.HandlerFunc(apiCfg.handleUnblockUser)))
+	mux.Handle("POST /api/users/{userId}/mute", authn.Required(http.HandlerFunc(apiCfg.handleMuteUser)))
+	mux.Handle("DELETE /api/users/{userId}/mute", authn.Required(http.HandlerFunc(apiCfg.handleUnmuteUser)))
+	mux.Handle("GET /api/users/{userId}/followers", authn.Optional(http.HandlerFunc(apiCfg.handleGetFollowers)))
+	mux.Handle("GET /api/users/{userId}/following", authn.Optional(http.HandlerFunc(apiCfg.handleGetFollowing)))
 	mux.HandleFunc("POST /api/login", apiCfg.handleLogin)
 	mux.HandleFunc("POST /api/refresh", apiCfg.handleRefreshToken)
-	mux.HandleFunc("POST /api/chirps", apiCfg.handleCreateChirp)
-	mux.HandleFunc("GET /api/chirps", apiCfg.handleGetChirpList)
-	mux.HandleFunc("GET /api/chirps/{chirpId}", apiCfg.handleGetChirp)
+	mux.HandleFunc("POST /api/revoke", apiCfg.handleRevokeToken)
+	mux.Handle("POST /api/logout-all", authn.Required(http.HandlerFunc(apiCfg.handleLogoutAll)))
+	mux.Handle("GET /api/sessions", authn.Required(http.HandlerFunc(apiCfg.handleListSessions)))
+	mux.Handle("DELETE /api/sessions/{sessionId}", authn.Required(http.HandlerFunc(apiCfg.handleDeleteSession)))
+	mux.Handle("POST /api/chirps", authn.Required(http.HandlerFunc(apiCfg.handleCreateChirp)))
+	mux.Handle("GET /api/chirps", authn.Optional(http.HandlerFunc(apiCfg.handleGetChirpList)))
+	mux.Handle("GET /api/chirps/search", authn.Optional(http.HandlerFunc(apiCfg.handleSearchChirps)))
+	mux.Handle("GET /api/chirps/{chirpId}", authn.Optional(http.HandlerFunc(apiCfg.handleGetChirp)))
+	mux.Handle("PATCH /api/chirps/{chirpId}", authn.Required(http.HandlerFunc(apiCfg.handleEditChirp)))
+	mux.Handle("DELETE /api/chirps/{chirpId}", authn.Required(http.HandlerFunc(apiCfg.handleDeleteChirp)))
+	mux.Handle("GET /api/chirps/{chirpId}/revisions", authn.Optional(http.HandlerFunc(apiCfg.handleGetChirpRevisions)))
+	mux.Handle("POST /api/chirps/{chirpId}/like", authn.Required(http.HandlerFunc(apiCfg.handleLikeChirp)))
+	mux.Handle("DELETE /api/chirps/{chirpId}/like", authn.Required(http.HandlerFunc(apiCfg.handleUnlikeChirp)))
+	mux.Handle("GET /api/chirps/{chirpId}/thread", authn.Optional(http.HandlerFunc(apiCfg.handleGetChirpThread)))
+	mux.Handle("GET /api/timeline", authn.Required(http.HandlerFunc(apiCfg.handleGetTimeline)))
+	mux.Handle("GET /api/hashtags/{tag}/chirps", authn.Optional(http.HandlerFunc(apiCfg.handleGetHashtagChirps)))
+	mux.HandleFunc("GET /api/trending", apiCfg.handleGetTrending)
+	go apiCfg.runTrendingWorker(context.Background())
+	go apiCfg.runTimelineWorker(context.Background())
 	log.Printf("Server listening on port %s", PORT)
 	log.Fatal(srv.ListenAndServe())
 }
+
+// loadKeyring reads the signing keys from JWT_KEY_DIR. Without a key
+// directory an ephemeral key is generated, which logs everyone out on
+// restart, so that is only allowed on the dev platform.
+func loadKeyring(platform string) (*auth.Keyring, error) {
+	dir := os.Getenv("JWT_KEY_DIR")
+	if dir == "" {
+		if platform != "dev" {
+			return nil, errors.New("no JWT_KEY_DIR in .env")
+		}
+		log.Print("No JWT_KEY_DIR in .env, generating an ephemeral signing key")
+		key, err := auth.GenerateKey("dev")
+		if err != nil {
+			return nil, err
+		}
+		return auth.NewKeyring(key)
+	}
+	return auth.LoadKeyring(dir, os.Getenv("JWT_ACTIVE_KID"))
+}
+
+// loadTombstoneKey reads the secret used to hash the ids of deleted
+// accounts. Outside dev it is required, since a key that changes between
+// restarts makes the stored hashes useless.
+func loadTombstoneKey(platform string) ([]byte, error) {
+	key := os.Getenv("TOMBSTONE_KEY")
+	if key == "" {
+		if platform != "dev" {
+			return nil, errors.New("no TOMBSTONE_KEY in .env")
+		}
+		log.Print("No TOMBSTONE_KEY in .env, generating an ephemeral key")
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		return random, nil
+	}
+	if len(key) < 32 {
+		return nil, errors.New("TOMBSTONE_KEY must be at least 32 characters")
+	}
+	return []byte(key), nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"gitea.rannes.dev/christian/chirpy/internal/auth"
+	"gitea.rannes.dev/christian/chirpy/internal/chirptext"
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+)
+
+// linkMentions replaces the mention links of chirp with the users mentioned
+// in its current body. Handles that don't belong to anyone are ignored.
+func linkMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := q.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+		return err
+	}
+	handles := chirptext.Mentions(chirp.Body)
+	if len(handles) == 0 {
+		return nil
+	}
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		err := q.LinkChirpMention(ctx, database.LinkChirpMentionParams{
+			ChirpID: chirp.ID,
+			UserID:  user.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handleGetMentions(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	page, err := parsePageQuery(r)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	cfg.writeChirpPage(w, r, page, func(ctx context.Context, page pageQuery) ([]database.Chirp, error) {
+		return cfg.db.ListUserMentions(ctx, database.ListUserMentionsParams{
+			UserID:          userId,
+			CursorCreatedAt: page.CursorCreatedAt,
+			CursorID:        page.CursorID,
+			RowLimit:        page.rowLimit(),
+		})
+	})
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitea.rannes.dev/christian/chirpy/internal/auth"
+	"gitea.rannes.dev/christian/chirpy/internal/chirptext"
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// What listing endpoints do with a chirp that matches a muted term.
+const (
+	mutedActionHide = "hide"
+	mutedActionFlag = "flag"
+)
+
+type jsonMutedWord struct {
+	ID        uuid.UUID  `json:"id"`
+	Kind      string     `json:"kind"`
+	Value     string     `json:"value"`
+	Action    string     `json:"action"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func toJsonMutedWord(word database.MutedWord) jsonMutedWord {
+	w := jsonMutedWord{
+		ID:        word.ID,
+		Kind:      word.Kind,
+		Value:     word.Value,
+		Action:    word.Action,
+		CreatedAt: word.CreatedAt,
+	}
+	if word.ExpiresAt.Valid {
+		w.ExpiresAt = &word.ExpiresAt.Time
+	}
+	return w
+}
+
+func (cfg *apiConfig) handleListMutedWords(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	words, err := cfg.db.ListMutedWords(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching muted words: %s", err))
+		return
+	}
+	resp := []jsonMutedWord{}
+	for _, word := range words {
+		resp = append(resp, toJsonMutedWord(word))
+	}
+	writeResponse(w, 200, resp)
+}
+
+// handleCreateMutedWord adds a muted term, or updates the action and expiry
+// of the same term if it is already muted.
+func (cfg *apiConfig) handleCreateMutedWord(w http.ResponseWriter, r *http.Request) {
+	type mutedWordInsert struct {
+		Kind      string     `json:"kind"`
+		Value     string     `json:"value"`
+		Action    string     `json:"action"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	userId, _ := auth.UserIDFromContext(r.Context())
+	payload := mutedWordInsert{Kind: chirptext.MuteWord, Action: mutedActionHide}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondWithError(w, 400, "Error decoding request body")
+		return
+	}
+	value, err := chirptext.NormalizeMutedTerm(payload.Kind, payload.Value)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	if payload.Action != mutedActionHide && payload.Action != mutedActionFlag {
+		respondWithError(w, 400, "action must be hide or flag")
+		return
+	}
+	var expiresAt sql.NullTime
+	if payload.ExpiresAt != nil {
+		if !payload.ExpiresAt.After(time.Now()) {
+			respondWithError(w, 400, "expires_at must be in the future")
+			return
+		}
+		expiresAt = sql.NullTime{Time: payload.ExpiresAt.UTC(), Valid: true}
+	}
+	word, err := cfg.db.UpsertMutedWord(r.Context(), database.UpsertMutedWordParams{
+		UserID:    userId,
+		Kind:      payload.Kind,
+		Value:     value,
+		Action:    payload.Action,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error saving the muted word: %s", err))
+		return
+	}
+	writeResponse(w, 201, toJsonMutedWord(word))
+}
+
+func (cfg *apiConfig) handleDeleteMutedWord(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("mutedWordId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	n, err := cfg.db.DeleteMutedWord(r.Context(), database.DeleteMutedWordParams{ID: id, UserID: userId})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error deleting the muted word: %s", err))
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, fmt.Sprintf("Muted word with id %s does not exist", id))
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// applyMutedWords drops or flags the chirps that match one of the viewer's
+// active muted terms. Stored chirps are never changed, and the viewer's own
+// chirps are left alone. A rechirp or quote also matches on its original.
+func (cfg *apiConfig) applyMutedWords(ctx context.Context, chirps []chirpSelect) ([]chirpSelect, error) {
+	viewerId, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return chirps, nil
+	}
+	words, err := cfg.db.ListMutedWords(ctx, viewerId)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return chirps, nil
+	}
+	kept := []chirpSelect{}
+	for _, c := range chirps {
+		action := ""
+		if c.UserID != viewerId {
+			action = mutedAction(words, c)
+		}
+		switch action {
+		case mutedActionHide:
+			continue
+		case mutedActionFlag:
+			c.Filtered = true
+		}
+		kept = append(kept, c)
+	}
+	return kept, nil
+}
+
+// mutedAction returns the action of the matching term, preferring hide over
+// flag, or "" if nothing matches.
+func mutedAction(words []database.MutedWord, c chirpSelect) string {
+	bodies := []string{c.Body}
+	for _, original := range []*chirpSelect{c.RechirpOf, c.QuoteOf} {
+		if original != nil {
+			bodies = append(bodies, original.Body)
+		}
+	}
+	action := ""
+	for _, word := range words {
+		term := chirptext.MutedTerm{Kind: word.Kind, Value: word.Value}
+		for _, body := range bodies {
+			if !term.Matches(body) {
+				continue
+			}
+			if word.Action == mutedActionHide {
+				return mutedActionHide
+			}
+			action = word.Action
+		}
+	}
+	return action
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+	// maxPageFetches bounds how many batches writeChirpPage reads to fill a
+	// page whose chirps are hidden by the viewer's muted words.
+	maxPageFetches = 5
+)
+
+// pageQuery is a keyset page request. The cursor is the (created_at, id) of
+// the last chirp on the previous page.
+type pageQuery struct {
+	Limit           int
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+}
+
+type chirpPage struct {
+	Chirps     []chirpSelect `json:"chirps"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func parsePageQuery(r *http.Request) (pageQuery, error) {
+	limit, err := parsePageLimit(r)
+	if err != nil {
+		return pageQuery{}, err
+	}
+	page, err := pageQuery{Limit: limit}.after(r.URL.Query().Get("cursor"))
+	if err != nil {
+		return page, errors.New("cursor is invalid")
+	}
+	return page, nil
+}
+
+// parsePageLimit reads the limit query parameter, defaulting to
+// defaultPageLimit and capped at maxPageLimit.
+func parsePageLimit(r *http.Request) (int, error) {
+	limit := r.URL.Query().Get("limit")
+	if limit == "" {
+		return defaultPageLimit, nil
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	return min(n, maxPageLimit), nil
+}
+
+// after returns the page that continues after cursor, or p itself when
+// cursor is "".
+func (p pageQuery) after(cursor string) (pageQuery, error) {
+	if cursor == "" {
+		return p, nil
+	}
+	createdAt, id, err := decodeCursor(cursor)
+	if err != nil {
+		return p, err
+	}
+	p.CursorCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+	p.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	return p, nil
+}
+
+// rowLimit is the number of rows to fetch. One extra row tells us whether
+// there is a next page without a separate count query.
+func (p pageQuery) rowLimit() int32 {
+	return int32(p.Limit + 1)
+}
+
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, errors.New("malformed cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	return t, parsedId, nil
+}
+
+// chirpFetcher returns up to page.rowLimit() chirps after page's cursor.
+type chirpFetcher func(ctx context.Context, page pageQuery) ([]database.Chirp, error)
+
+// pagedChirp is a chirp from a listing and the cursor that resumes the
+// listing after it.
+type pagedChirp struct {
+	Chirp  database.Chirp
+	Cursor string
+}
+
+// chirpPager returns up to limit+1 chirps of a listing following cursor,
+// or from the start of the requested page when cursor is "".
+type chirpPager func(ctx context.Context, cursor string) ([]pagedChirp, error)
+
+// writeChirpPage writes a page of a listing ordered by (created_at, id).
+func (cfg *apiConfig) writeChirpPage(w http.ResponseWriter, r *http.Request, page pageQuery, fetch chirpFetcher) {
+	cfg.writePagedChirps(w, r, page.Limit, func(ctx context.Context, cursor string) ([]pagedChirp, error) {
+		next, err := page.after(cursor)
+		if err != nil {
+			return nil, err
+		}
+		chirps, err := fetch(ctx, next)
+		paged := make([]pagedChirp, len(chirps))
+		for i, chirp := range chirps {
+			paged[i] = pagedChirp{Chirp: chirp, Cursor: encodeCursor(chirp.CreatedAt, chirp.ID)}
+		}
+		return paged, err
+	})
+}
+
+// writePagedChirps renders a page of up to limit chirps from fetch and sets
+// the next cursor. Chirps hidden by the viewer's muted words don't count
+// towards the limit, so it keeps fetching past them until the page is full,
+// the listing ends or maxPageFetches batches have been read. The next
+// cursor is that of the last chirp returned or, if the page is short, the
+// last one skipped.
+func (cfg *apiConfig) writePagedChirps(w http.ResponseWriter, r *http.Request, limit int, fetch chirpPager) {
+	resp := chirpPage{Chirps: []chirpSelect{}}
+	for range maxPageFetches {
+		paged, err := fetch(r.Context(), resp.NextCursor)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("There was an error fetching chirps: %s", err))
+			return
+		}
+		more := len(paged) > limit
+		paged = paged[:min(len(paged), limit)]
+		chirps := make([]database.Chirp, len(paged))
+		cursors := map[uuid.UUID]string{}
+		for i, p := range paged {
+			chirps[i] = p.Chirp
+			cursors[p.Chirp.ID] = p.Cursor
+		}
+		rendered, err := cfg.renderChirps(r.Context(), chirps)
+		if err == nil {
+			rendered, err = cfg.applyMutedWords(r.Context(), rendered)
+		}
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("There was an error fetching chirps: %s", err))
+			return
+		}
+
+		resp.NextCursor = ""
+		need := limit - len(resp.Chirps)
+		if len(rendered) >= need {
+			resp.Chirps = append(resp.Chirps, rendered[:need]...)
+			if more || len(rendered) > need {
+				resp.NextCursor = cursors[rendered[need-1].ID]
+			}
+			break
+		}
+		resp.Chirps = append(resp.Chirps, rendered...)
+		if !more {
+			break
+		}
+		resp.NextCursor = paged[len(paged)-1].Cursor
+	}
+	if resp.NextCursor != "" {
+		setNextLink(w, r, resp.NextCursor, limit)
+	}
+	writeResponse(w, 200, resp)
+}
+
+// setNextLink points the Link header at the same request with the cursor
+// of the next page.
+func setNextLink(w http.ResponseWriter, r *http.Request, cursor string, limit int) {
+	next := *r.URL
+	query := next.Query()
+	query.Set("cursor", cursor)
+	query.Set("limit", strconv.Itoa(limit))
+	next.RawQuery = query.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+}
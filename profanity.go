@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+	"gitea.rannes.dev/christian/chirpy/internal/profanity"
+)
+
+// defaultProfaneWords is used when PROFANITY_SOURCE is not set.
+var defaultProfaneWords = profanity.Words{"kerfuffle", "sharbert", "fornax"}
+
+// dbWordSource reads the word list from the profane_words table.
+type dbWordSource struct {
+	db *database.Queries
+}
+
+func (s dbWordSource) Words(ctx context.Context) ([]string, error) {
+	return s.db.ListProfaneWords(ctx)
+}
+
+// profanitySource picks the word list from PROFANITY_SOURCE: "db" for the
+// profane_words table, "file" for the file in PROFANITY_FILE, or the built-in
+// list when unset.
+func profanitySource(db *database.Queries) profanity.Source {
+	switch source := os.Getenv("PROFANITY_SOURCE"); source {
+	case "db":
+		return dbWordSource{db: db}
+	case "file":
+		path := os.Getenv("PROFANITY_FILE")
+		if path == "" {
+			log.Fatal("PROFANITY_SOURCE is file but there is no PROFANITY_FILE in .env")
+		}
+		return profanity.FileSource(path)
+	case "":
+		return defaultProfaneWords
+	default:
+		log.Fatalf("Invalid PROFANITY_SOURCE: %s", source)
+		return nil
+	}
+}
+
+// handleReloadProfanity reloads the word list so edits to the file or table
+// take effect without a restart. Chirps already stored are not re-censored.
+func (cfg *apiConfig) handleReloadProfanity(w http.ResponseWriter, r *http.Request) {
+	type reloadResult struct {
+		Words int `json:"words"`
+	}
+	n, err := cfg.profanityList.Reload(r.Context())
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error reloading the word list: %s", err))
+		return
+	}
+	writeResponse(w, 200, reloadResult{Words: n})
+}
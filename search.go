@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+	"gitea.rannes.dev/christian/chirpy/internal/search"
+	"github.com/google/uuid"
+)
+
+// handleSearchChirps returns the chirps matching q, most relevant first.
+// Results are ordered by rank rather than time, so the cursor carries the
+// rank of the last chirp as well.
+func (cfg *apiConfig) handleSearchChirps(w http.ResponseWriter, r *http.Request) {
+	tsQuery, err := search.ParseQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		respondWithError(w, 400, "q must contain at least one word to search for")
+		return
+	}
+	limit, err := parsePageLimit(r)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	start := r.URL.Query().Get("cursor")
+	if _, err := decodeSearchCursor(start); err != nil {
+		respondWithError(w, 400, "cursor is invalid")
+		return
+	}
+	cfg.writePagedChirps(w, r, limit, func(ctx context.Context, cursor string) ([]pagedChirp, error) {
+		if cursor == "" {
+			cursor = start
+		}
+		params, err := decodeSearchCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		params.Query = tsQuery
+		params.ViewerID = viewerID(ctx)
+		params.RowLimit = int32(limit + 1)
+		rows, err := cfg.db.SearchChirps(ctx, params)
+		paged := make([]pagedChirp, len(rows))
+		for i, row := range rows {
+			paged[i] = pagedChirp{
+				Chirp: database.Chirp{
+					ID:        row.ID,
+					CreatedAt: row.CreatedAt,
+					UpdatedAt: row.UpdatedAt,
+					Body:      row.Body,
+					UserID:    row.UserID,
+					ParentID:  row.ParentID,
+					RootID:    row.RootID,
+					Kind:      row.Kind,
+					RechirpOf: row.RechirpOf,
+					QuoteOf:   row.QuoteOf,
+				},
+				Cursor: encodeSearchCursor(row.Rank, row.CreatedAt, row.ID),
+			}
+		}
+		return paged, err
+	})
+}
+
+func encodeSearchCursor(rank float32, createdAt time.Time, id uuid.UUID) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + "|" + createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeSearchCursor returns the search parameters that continue after
+// cursor, or empty ones when cursor is "".
+func decodeSearchCursor(cursor string) (database.SearchChirpsParams, error) {
+	params := database.SearchChirpsParams{}
+	if cursor == "" {
+		return params, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return params, err
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return params, errors.New("malformed cursor")
+	}
+	rank, err := strconv.ParseFloat(parts[0], 32)
+	if err != nil {
+		return params, err
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return params, err
+	}
+	id, err := uuid.Parse(parts[2])
+	if err != nil {
+		return params, err
+	}
+	params.CursorRank = sql.NullFloat64{Float64: rank, Valid: true}
+	params.CursorCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+	params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	return params, nil
+}
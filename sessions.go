@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitea.rannes.dev/christian/chirpy/internal/auth"
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// A session is one login on one device. Refresh token rotation keeps the
+// family_id stable, so the family id doubles as the session id and the raw
+// token values never leave the server.
+type jsonSession struct {
+	ID         uuid.UUID `json:"id"`
+	Label      string    `json:"label"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (cfg *apiConfig) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	rows, err := cfg.db.ListActiveSessions(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching sessions: %s", err))
+		return
+	}
+	sessions := []jsonSession{}
+	for _, row := range rows {
+		sessions = append(sessions, jsonSession{
+			ID:         row.FamilyID,
+			Label:      row.Label,
+			UserAgent:  row.UserAgent,
+			IPAddress:  row.IpAddress,
+			SignedInAt: row.SignedInAt,
+			LastUsedAt: row.LastUsedAt,
+			ExpiresAt:  row.ExpiresAt,
+		})
+	}
+	writeResponse(w, 200, sessions)
+}
+
+func (cfg *apiConfig) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(r.PathValue("sessionId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	revoked, err := cfg.db.RevokeRefreshTokenFamily(r.Context(), database.RevokeRefreshTokenFamilyParams{
+		FamilyID: id,
+		UserID:   userId,
+	})
+	if err != nil {
+		respondWithError(w, 500, "There was an error ending the session")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, 404, fmt.Sprintf("Session with id %s does not exist", id))
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// startSession creates a new refresh token family for userId and returns the
+// raw refresh token.
+func (cfg *apiConfig) startSession(ctx context.Context, q *database.Queries, r *http.Request, userId uuid.UUID, deviceName string) (string, error) {
+	refresh, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	err = q.InsertRefreshToken(ctx, database.InsertRefreshTokenParams{
+		Token:     refresh,
+		UserID:    userId,
+		ExpiresAt: time.Now().Add(cfg.resetExpiry),
+		FamilyID:  uuid.New(),
+		UserAgent: r.UserAgent(),
+		IpAddress: clientIP(r),
+		Label:     sessionLabel(deviceName, r.UserAgent()),
+	})
+	if err != nil {
+		return "", err
+	}
+	return refresh, nil
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func sessionLabel(deviceName, userAgent string) string {
+	const maxLabel = 100
+	label := strings.TrimSpace(deviceName)
+	if label == "" {
+		label = userAgent
+	}
+	if label == "" {
+		label = "Unknown device"
+	}
+	if runes := []rune(label); len(runes) > maxLabel {
+		label = string(runes[:maxLabel])
+	}
+	return label
+}
@@ -0,0 +1,34 @@
+-- name: BlockUser :execrows
+INSERT INTO
+  user_blocks (blocker_id, blocked_id, created_at)
+VALUES
+  ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM user_blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: IsBlockedBetween :one
+SELECT blocked_between(sqlc.arg('user_a'), sqlc.arg('user_b'));
+
+-- name: MuteUser :execrows
+INSERT INTO
+  user_mutes (muter_id, muted_id, created_at)
+VALUES
+  ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :execrows
+DELETE FROM user_mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: ListUserBlocks :many
+SELECT * FROM user_blocks
+WHERE blocker_id = $1
+ORDER BY created_at;
+
+-- name: ListUserMutes :many
+SELECT * FROM user_mutes
+WHERE muter_id = $1
+ORDER BY created_at;
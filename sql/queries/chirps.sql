@@ -1,8 +1,8 @@
 -- name: CreateChirp :one
 INSERT INTO
-  chirps (id, created_at, updated_at, body, user_id)
+  chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, kind, rechirp_of, quote_of)
 VALUES
-  (gen_random_uuid(), NOW(), NOW(), $1, $2)
+  (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: ListChirps :many
@@ -12,3 +12,136 @@ ORDER BY created_at;
 -- name: GetChirp :one
 SELECT * FROM chirps
 WHERE id = $1;
+
+-- name: GetChirpForUpdate :one
+-- GetChirpForUpdate locks the chirp until the transaction ends, so
+-- concurrent edits are applied one after the other.
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetVisibleChirp :one
+SELECT * FROM chirps
+WHERE chirps.id = sqlc.arg('id')
+  AND NOT blocked_between(chirps.user_id, sqlc.narg('viewer_id'));
+
+-- name: ListUserChirps :many
+SELECT * FROM chirps
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: CountUserChirps :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1;
+
+-- name: DeleteChirp :execrows
+DELETE FROM chirps
+WHERE id = $1;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: InsertChirpRevision :exec
+INSERT INTO
+  chirp_revisions (id, chirp_id, body, created_at, replaced_at)
+VALUES
+  (gen_random_uuid(), $1, $2, $3, NOW());
+
+-- name: ListChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY replaced_at;
+
+-- name: ListChirpsFilteredAsc :many
+SELECT * FROM chirps
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+  AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until'))
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND NOT hidden_from_viewer(chirps.user_id, sqlc.narg('viewer_id'))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListChirpsFilteredDesc :many
+SELECT * FROM chirps
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+  AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until'))
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND NOT hidden_from_viewer(chirps.user_id, sqlc.narg('viewer_id'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: SearchChirps :many
+-- Results are ordered by relevance and paged by (rank, created_at, id).
+SELECT * FROM (
+  SELECT chirps.*, ts_rank(chirps.search_vector, query)::real AS rank
+  FROM chirps, to_tsquery('english', sqlc.arg('query')) query
+  WHERE chirps.search_vector @@ query
+    AND NOT hidden_from_viewer(chirps.user_id, sqlc.narg('viewer_id'))
+) results
+WHERE sqlc.narg('cursor_rank')::real IS NULL
+  OR (rank, created_at, id) < (sqlc.narg('cursor_rank'), sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+  SELECT chirps.*, 1 AS depth FROM chirps
+  WHERE chirps.id = (SELECT c.parent_id FROM chirps c WHERE c.id = sqlc.arg('id'))
+  UNION ALL
+  SELECT chirps.*, ancestors.depth + 1 FROM chirps
+  JOIN ancestors ON chirps.id = ancestors.parent_id
+)
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM ancestors
+WHERE NOT blocked_between(ancestors.user_id, sqlc.narg('viewer_id'))
+ORDER BY depth DESC;
+
+-- name: ListChirpReplies :many
+-- The recursion stops at chirps hidden from the viewer, so replies below
+-- them are left out rather than shown without their parent.
+WITH RECURSIVE replies AS (
+  SELECT chirps.* FROM chirps
+  WHERE chirps.parent_id = sqlc.arg('chirp_id')
+    AND NOT hidden_from_viewer(chirps.user_id, sqlc.narg('viewer_id'))
+  UNION ALL
+  SELECT chirps.* FROM chirps
+  JOIN replies ON chirps.parent_id = replies.id
+  WHERE NOT hidden_from_viewer(chirps.user_id, sqlc.narg('viewer_id'))
+)
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, kind, rechirp_of, quote_of FROM replies
+WHERE sqlc.narg('cursor_created_at')::timestamp IS NULL
+  OR (created_at, id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('row_limit');
+
+-- name: CountChirpReplies :many
+-- Counts the replies below each chirp that ListChirpReplies returns to the
+-- viewer, so reply_count matches the thread.
+WITH RECURSIVE replies AS (
+  SELECT chirps.id, chirps.parent_id AS chirp_id FROM chirps
+  WHERE chirps.parent_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+    AND NOT hidden_from_viewer(chirps.user_id, sqlc.narg('viewer_id'))
+  UNION ALL
+  SELECT chirps.id, replies.chirp_id FROM chirps
+  JOIN replies ON chirps.parent_id = replies.id
+  WHERE NOT hidden_from_viewer(chirps.user_id, sqlc.narg('viewer_id'))
+)
+SELECT chirp_id::uuid AS chirp_id, COUNT(*) AS reply_count FROM replies
+GROUP BY chirp_id;
+
+-- name: ListChirpsByIDs :many
+SELECT * FROM chirps
+WHERE chirps.id = ANY(sqlc.arg('ids')::uuid[])
+  AND NOT blocked_between(chirps.user_id, sqlc.narg('viewer_id'));
+
+-- name: ListUserChirpRevisions :many
+SELECT chirp_revisions.* FROM chirp_revisions
+JOIN chirps ON chirps.id = chirp_revisions.chirp_id
+WHERE chirps.user_id = $1
+ORDER BY chirp_revisions.chirp_id, chirp_revisions.replaced_at;
@@ -0,0 +1,48 @@
+-- name: FollowUser :execrows
+INSERT INTO
+  follows (follower_id, followee_id, created_at)
+VALUES
+  ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: ListFollowers :many
+SELECT users.id, users.created_at, users.handle, follows.created_at AS followed_at FROM users
+JOIN follows ON follows.follower_id = users.id
+WHERE follows.followee_id = sqlc.arg('user_id')
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (follows.created_at, users.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND NOT blocked_between(users.id, sqlc.narg('viewer_id'))
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListFollowing :many
+SELECT users.id, users.created_at, users.handle, follows.created_at AS followed_at FROM users
+JOIN follows ON follows.followee_id = users.id
+WHERE follows.follower_id = sqlc.arg('user_id')
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (follows.created_at, users.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND NOT blocked_between(users.id, sqlc.narg('viewer_id'))
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: CountUserFollows :many
+SELECT
+  users.id AS user_id,
+  (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+  (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count
+FROM users
+WHERE users.id = ANY(sqlc.arg('user_ids')::uuid[]);
+
+-- name: DeleteFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg('user_a') AND followee_id = sqlc.arg('user_b'))
+  OR (follower_id = sqlc.arg('user_b') AND followee_id = sqlc.arg('user_a'));
+
+-- name: ListUserFollows :many
+SELECT * FROM follows
+WHERE follower_id = sqlc.arg('user_id') OR followee_id = sqlc.arg('user_id')
+ORDER BY created_at;
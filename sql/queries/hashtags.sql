@@ -0,0 +1,59 @@
+-- name: UpsertHashtag :one
+INSERT INTO
+  hashtags (id, tag, created_at)
+VALUES
+  (gen_random_uuid(), $1, NOW())
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING *;
+
+-- name: LinkChirpHashtag :exec
+INSERT INTO
+  chirp_hashtags (chirp_id, hashtag_id, created_at)
+VALUES
+  ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: ListHashtagChirps :many
+SELECT chirps.* FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = sqlc.arg('tag')
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND NOT hidden_from_viewer(chirps.user_id, sqlc.narg('viewer_id'))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: CountHashtagUsage :many
+SELECT
+  hashtags.tag,
+  COUNT(*) FILTER (
+    WHERE chirps.created_at >= NOW() - make_interval(secs => sqlc.arg('window_seconds')::double precision)
+  ) AS recent_count,
+  COUNT(*) FILTER (
+    WHERE chirps.created_at < NOW() - make_interval(secs => sqlc.arg('window_seconds')::double precision)
+  ) AS previous_count
+FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE chirps.created_at >= NOW() - make_interval(secs => 2 * sqlc.arg('window_seconds')::double precision)
+GROUP BY hashtags.tag;
+
+-- name: DeleteTrendingHashtags :exec
+DELETE FROM trending_hashtags
+WHERE window_name = $1;
+
+-- name: InsertTrendingHashtag :exec
+INSERT INTO
+  trending_hashtags (window_name, rank, tag, recent_count, previous_count, score, computed_at)
+VALUES
+  ($1, $2, $3, $4, $5, $6, NOW());
+
+-- name: ListTrendingHashtags :many
+SELECT * FROM trending_hashtags
+WHERE window_name = $1
+ORDER BY rank;
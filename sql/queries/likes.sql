@@ -0,0 +1,37 @@
+-- name: LikeChirp :execrows
+INSERT INTO
+  chirp_likes (chirp_id, user_id, created_at)
+VALUES
+  ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnlikeChirp :execrows
+DELETE FROM chirp_likes
+WHERE chirp_id = $1 AND user_id = $2;
+
+-- name: CountChirpLikes :many
+SELECT chirp_id, COUNT(*) AS like_count FROM chirp_likes
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY chirp_id;
+
+-- name: ListLikedChirpIDs :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = sqlc.arg('user_id')
+  AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListUserLikes :many
+-- Pages through a user's likes, most recently liked first. The cursor is
+-- the like's created_at and the chirp id.
+SELECT sqlc.embed(chirps), chirp_likes.created_at AS liked_at FROM chirps
+JOIN chirp_likes ON chirp_likes.chirp_id = chirps.id
+WHERE chirp_likes.user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirp_likes.created_at, chirp_likes.chirp_id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND NOT hidden_from_viewer(chirps.user_id, sqlc.narg('viewer_id'))
+ORDER BY chirp_likes.created_at DESC, chirp_likes.chirp_id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListUserChirpLikes :many
+SELECT * FROM chirp_likes
+WHERE user_id = $1
+ORDER BY created_at;
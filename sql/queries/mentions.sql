@@ -0,0 +1,22 @@
+-- name: LinkChirpMention :exec
+-- Users who blocked the author, or were blocked by them, are not linked.
+INSERT INTO
+  chirp_mentions (chirp_id, user_id, created_at)
+SELECT chirps.id, sqlc.arg('user_id'), NOW() FROM chirps
+WHERE chirps.id = sqlc.arg('chirp_id')
+  AND NOT blocked_between(chirps.user_id, sqlc.arg('user_id'))
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
+
+-- name: ListUserMentions :many
+SELECT chirps.* FROM chirps
+JOIN chirp_mentions ON chirp_mentions.chirp_id = chirps.id
+WHERE chirp_mentions.user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND NOT hidden_from_viewer(chirps.user_id, sqlc.arg('user_id'))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('row_limit');
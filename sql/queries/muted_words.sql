@@ -0,0 +1,23 @@
+-- name: UpsertMutedWord :one
+INSERT INTO
+  muted_words (id, user_id, kind, value, action, created_at, expires_at)
+VALUES
+  (gen_random_uuid(), $1, $2, $3, $4, NOW(), $5)
+ON CONFLICT (user_id, kind, value) DO UPDATE
+SET action = EXCLUDED.action, expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: ListMutedWords :many
+SELECT * FROM muted_words
+WHERE user_id = $1
+  AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY created_at;
+
+-- name: DeleteMutedWord :execrows
+DELETE FROM muted_words
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListAllMutedWords :many
+SELECT * FROM muted_words
+WHERE user_id = $1
+ORDER BY created_at;
@@ -0,0 +1,3 @@
+-- name: ListProfaneWords :many
+SELECT word FROM profane_words
+ORDER BY word;
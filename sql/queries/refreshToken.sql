@@ -1,10 +1,48 @@
 -- name: InsertRefreshToken :exec
 INSERT INTO
-  refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
+  refresh_tokens (token, created_at, updated_at, user_id, expires_at, family_id, user_agent, ip_address, last_used_at, label)
 VALUES
-  ($1, NOW(), NOW(), $2, $3, $4);
+  ($1, NOW(), NOW(), $2, $3, $4, $5, $6, NOW(), $7);
 
 -- name: GetRefreshToken :one
 SELECT * FROM refresh_tokens
 WHERE token = $1;
 
+-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW(), replaced_by = $2
+WHERE token = $1 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE token = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: ListActiveSessions :many
+SELECT
+  family_id,
+  label,
+  user_agent,
+  ip_address,
+  last_used_at,
+  expires_at,
+  (SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = refresh_tokens.family_id)::timestamp AS signed_in_at
+FROM refresh_tokens
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY last_used_at DESC;
+
+-- name: ListUserSessionHistory :many
+SELECT family_id, created_at, expires_at, revoked_at, user_agent, ip_address, last_used_at, label
+FROM refresh_tokens
+WHERE user_id = $1
+ORDER BY created_at;
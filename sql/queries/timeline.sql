@@ -0,0 +1,28 @@
+-- name: ListTimeline :many
+-- Reads a page of the materialized timeline through
+-- timeline_entries_user_id_created_at_chirp_id_idx.
+SELECT chirps.* FROM timeline_entries
+JOIN chirps ON chirps.id = timeline_entries.chirp_id
+WHERE timeline_entries.user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (timeline_entries.created_at, timeline_entries.chirp_id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND NOT hidden_from_viewer(chirps.user_id, sqlc.arg('user_id'))
+ORDER BY timeline_entries.created_at DESC, timeline_entries.chirp_id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: PruneTimelines :execrows
+-- Deletes everything past the newest keep entries of each timeline, finding
+-- the cutoff per user through the timeline index.
+DELETE FROM timeline_entries
+USING (
+  SELECT users.id AS user_id, cutoff.created_at, cutoff.chirp_id FROM users
+  CROSS JOIN LATERAL (
+    SELECT created_at, chirp_id FROM timeline_entries
+    WHERE timeline_entries.user_id = users.id
+    ORDER BY created_at DESC, chirp_id DESC
+    OFFSET sqlc.arg('keep')
+    LIMIT 1
+  ) cutoff
+) oldest
+WHERE timeline_entries.user_id = oldest.user_id
+  AND (timeline_entries.created_at, timeline_entries.chirp_id) <= (oldest.created_at, oldest.chirp_id);
@@ -1,8 +1,8 @@
 -- name: CreateUser :one
 INSERT INTO
-  users (id, created_at, updated_at, email, hashed_password)
+  users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES
-  (gen_random_uuid(), NOW(), NOW(), $1, $2)
+  (gen_random_uuid(), NOW(), NOW(), $1, $2, $3)
 RETURNING *;
 
 -- name: GetUser :one
@@ -11,3 +11,34 @@ WHERE email = $1;
 
 -- name: ResetUsers :execrows
 DELETE FROM users;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: UpdateUser :one
+UPDATE users
+SET email = $2, hashed_password = $3, handle = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE id = $1;
+
+-- name: InsertDeletedUser :exec
+INSERT INTO
+  deleted_users (id, deleted_at, user_hash, account_created_at, chirp_count, session_count)
+SELECT
+  gen_random_uuid(),
+  NOW(),
+  $2,
+  users.created_at,
+  (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id),
+  (SELECT COUNT(DISTINCT family_id) FROM refresh_tokens WHERE refresh_tokens.user_id = users.id)
+FROM users
+WHERE users.id = $1;
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users
+WHERE LOWER(handle) = ANY(sqlc.arg('handles')::text[]);
@@ -0,0 +1,22 @@
+-- +goose Up
+DELETE FROM refresh_tokens
+WHERE user_id NOT IN (SELECT id FROM users);
+
+ALTER TABLE refresh_tokens
+ADD CONSTRAINT refresh_tokens_user_id_fkey
+FOREIGN KEY (user_id) REFERENCES users ON DELETE CASCADE;
+
+CREATE TABLE deleted_users (
+  id UUID PRIMARY KEY,
+  deleted_at TIMESTAMP NOT NULL,
+  user_hash TEXT NOT NULL,
+  account_created_at TIMESTAMP NOT NULL,
+  chirp_count BIGINT NOT NULL,
+  session_count BIGINT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE deleted_users;
+
+ALTER TABLE refresh_tokens
+DROP CONSTRAINT refresh_tokens_user_id_fkey;
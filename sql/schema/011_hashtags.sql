@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE hashtags (
+  id UUID PRIMARY KEY,
+  tag TEXT NOT NULL UNIQUE,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE chirp_hashtags (
+  chirp_id UUID NOT NULL REFERENCES chirps ON DELETE CASCADE,
+  hashtag_id UUID NOT NULL REFERENCES hashtags ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (chirp_id, hashtag_id)
+);
+
+CREATE INDEX chirp_hashtags_hashtag_id_idx ON chirp_hashtags (hashtag_id);
+
+-- +goose Down
+DROP TABLE chirp_hashtags;
+DROP TABLE hashtags;
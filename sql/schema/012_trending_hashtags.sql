@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE trending_hashtags (
+  window_name TEXT NOT NULL,
+  rank INTEGER NOT NULL,
+  tag TEXT NOT NULL,
+  recent_count BIGINT NOT NULL,
+  previous_count BIGINT NOT NULL,
+  score DOUBLE PRECISION NOT NULL,
+  computed_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (window_name, rank)
+);
+
+-- +goose Down
+DROP TABLE trending_hashtags;
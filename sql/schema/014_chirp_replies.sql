@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN parent_id UUID REFERENCES chirps ON DELETE SET NULL,
+ADD COLUMN root_id UUID REFERENCES chirps ON DELETE SET NULL;
+
+CREATE INDEX chirps_parent_id_idx ON chirps (parent_id);
+CREATE INDEX chirps_root_id_idx ON chirps (root_id);
+
+-- +goose Down
+DROP INDEX chirps_root_id_idx;
+DROP INDEX chirps_parent_id_idx;
+
+ALTER TABLE chirps
+DROP COLUMN root_id,
+DROP COLUMN parent_id;
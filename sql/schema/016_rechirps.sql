@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN kind TEXT NOT NULL DEFAULT 'chirp',
+ADD COLUMN rechirp_of UUID REFERENCES chirps ON DELETE CASCADE,
+ADD COLUMN quote_of UUID REFERENCES chirps ON DELETE SET NULL;
+
+-- A user can rechirp a chirp once. Quotes are not limited.
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_idx ON chirps (user_id, rechirp_of)
+WHERE rechirp_of IS NOT NULL;
+
+CREATE INDEX chirps_quote_of_idx ON chirps (quote_of);
+
+-- +goose Down
+DROP INDEX chirps_quote_of_idx;
+
+DROP INDEX chirps_user_id_rechirp_of_idx;
+
+ALTER TABLE chirps
+DROP COLUMN quote_of,
+DROP COLUMN rechirp_of,
+DROP COLUMN kind;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE muted_words (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+  kind TEXT NOT NULL,
+  value TEXT NOT NULL,
+  action TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  expires_at TIMESTAMP,
+  UNIQUE (user_id, kind, value)
+);
+
+-- +goose Down
+DROP TABLE muted_words;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE profane_words (
+  word TEXT PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO profane_words (word) VALUES ('kerfuffle'), ('sharbert'), ('fornax');
+
+-- +goose Down
+DROP TABLE profane_words;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- The block and mute rules shared by every query that returns chirps to a
+-- viewer. A NULL viewer is never blocked or muting anyone.
+
+-- +goose StatementBegin
+CREATE FUNCTION blocked_between(user_a UUID, user_b UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+  SELECT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = user_a AND blocked_id = user_b)
+      OR (blocker_id = user_b AND blocked_id = user_a)
+  )
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION hidden_from_viewer(author_id UUID, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+  SELECT blocked_between(author_id, viewer_id) OR EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE muter_id = viewer_id AND muted_id = author_id
+  )
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION hidden_from_viewer;
+
+DROP FUNCTION blocked_between;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Admins are granted directly in the database.
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN is_admin;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Hashes written before this migration are plain SHA-256 of a public user
+-- id and can be reversed by anyone holding the id, so they are dropped.
+ALTER TABLE deleted_users
+ALTER COLUMN user_hash DROP NOT NULL;
+
+UPDATE deleted_users SET user_hash = NULL;
+
+-- +goose Down
+DELETE FROM deleted_users WHERE user_hash IS NULL;
+
+ALTER TABLE deleted_users
+ALTER COLUMN user_hash SET NOT NULL;
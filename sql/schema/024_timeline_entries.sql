@@ -0,0 +1,83 @@
+-- +goose Up
+-- Each user's timeline is materialized: a row per chirp from the user or
+-- anyone they follow. Triggers keep it current, so reading a page is one
+-- index range scan regardless of how many accounts the user follows.
+CREATE TABLE timeline_entries (
+  user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+  chirp_id UUID NOT NULL REFERENCES chirps ON DELETE CASCADE,
+  author_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX timeline_entries_user_id_created_at_chirp_id_idx ON timeline_entries (user_id, created_at DESC, chirp_id DESC);
+
+CREATE INDEX timeline_entries_user_id_author_id_idx ON timeline_entries (user_id, author_id);
+
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT user_id, id, user_id, created_at FROM chirps
+UNION ALL
+SELECT follows.follower_id, chirps.id, chirps.user_id, chirps.created_at FROM follows
+JOIN chirps ON chirps.user_id = follows.followee_id;
+
+-- +goose StatementBegin
+CREATE FUNCTION timeline_add_chirp() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+  INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+  SELECT NEW.user_id, NEW.id, NEW.user_id, NEW.created_at
+  UNION ALL
+  SELECT follower_id, NEW.id, NEW.user_id, NEW.created_at FROM follows
+  WHERE followee_id = NEW.user_id;
+  RETURN NULL;
+END
+$$;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_timeline_insert AFTER INSERT ON chirps
+FOR EACH ROW EXECUTE FUNCTION timeline_add_chirp();
+
+-- +goose StatementBegin
+CREATE FUNCTION timeline_add_followee() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+  INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+  SELECT NEW.follower_id, id, user_id, created_at FROM chirps
+  WHERE user_id = NEW.followee_id
+  ON CONFLICT DO NOTHING;
+  RETURN NULL;
+END
+$$;
+-- +goose StatementEnd
+
+CREATE TRIGGER follows_timeline_insert AFTER INSERT ON follows
+FOR EACH ROW EXECUTE FUNCTION timeline_add_followee();
+
+-- +goose StatementBegin
+CREATE FUNCTION timeline_remove_followee() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+  DELETE FROM timeline_entries
+  WHERE user_id = OLD.follower_id AND author_id = OLD.followee_id;
+  RETURN NULL;
+END
+$$;
+-- +goose StatementEnd
+
+CREATE TRIGGER follows_timeline_delete AFTER DELETE ON follows
+FOR EACH ROW EXECUTE FUNCTION timeline_remove_followee();
+
+-- +goose Down
+DROP TRIGGER follows_timeline_delete ON follows;
+
+DROP TRIGGER follows_timeline_insert ON follows;
+
+DROP TRIGGER chirps_timeline_insert ON chirps;
+
+DROP FUNCTION timeline_remove_followee;
+
+DROP FUNCTION timeline_add_followee;
+
+DROP FUNCTION timeline_add_chirp;
+
+DROP TABLE timeline_entries;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- Replies are walked through parent_id, so when chirps are deleted their
+-- replies move up to the nearest surviving ancestor instead of being cut
+-- off from the thread. This runs for every delete, including the cascade
+-- from a deleted account. The foreign key is checked at commit so the
+-- trigger can fix the references after the whole statement has run.
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_parent_id_fkey,
+ADD CONSTRAINT chirps_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES chirps
+  DEFERRABLE INITIALLY DEFERRED;
+
+-- +goose StatementBegin
+CREATE FUNCTION reparent_replies() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+  -- A parent may itself have been deleted by the same statement, so keep
+  -- moving replies up until none point at a deleted chirp.
+  LOOP
+    UPDATE chirps SET parent_id = deleted.parent_id
+    FROM deleted
+    WHERE chirps.parent_id = deleted.id;
+    EXIT WHEN NOT FOUND;
+  END LOOP;
+  RETURN NULL;
+END
+$$;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_reparent_replies AFTER DELETE ON chirps
+REFERENCING OLD TABLE AS deleted
+FOR EACH STATEMENT EXECUTE FUNCTION reparent_replies();
+
+-- +goose Down
+DROP TRIGGER chirps_reparent_replies ON chirps;
+
+DROP FUNCTION reparent_replies;
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_parent_id_fkey,
+ADD CONSTRAINT chirps_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES chirps ON DELETE SET NULL;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Following someone only backfills their newest chirps instead of their
+-- whole history. Older chirps are still on their profile.
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION timeline_add_followee() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+  INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+  SELECT NEW.follower_id, id, user_id, created_at FROM chirps
+  WHERE user_id = NEW.followee_id
+  ORDER BY created_at DESC, id DESC
+  LIMIT 100
+  ON CONFLICT DO NOTHING;
+  RETURN NULL;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION timeline_add_followee() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+  INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+  SELECT NEW.follower_id, id, user_id, created_at FROM chirps
+  WHERE user_id = NEW.followee_id
+  ON CONFLICT DO NOTHING;
+  RETURN NULL;
+END
+$$;
+-- +goose StatementEnd
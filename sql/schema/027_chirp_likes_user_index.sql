@@ -0,0 +1,10 @@
+-- +goose Up
+-- A user's likes are listed most recently liked first.
+CREATE INDEX chirp_likes_user_id_created_at_chirp_id_idx ON chirp_likes (user_id, created_at DESC, chirp_id DESC);
+
+DROP INDEX chirp_likes_user_id_idx;
+
+-- +goose Down
+CREATE INDEX chirp_likes_user_id_idx ON chirp_likes (user_id);
+
+DROP INDEX chirp_likes_user_id_created_at_chirp_id_idx;
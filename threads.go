@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type chirpThread struct {
+	Chirp      chirpSelect   `json:"chirp"`
+	Ancestors  []chirpSelect `json:"ancestors"`
+	Replies    []chirpSelect `json:"replies"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// handleGetChirpThread returns a chirp with the chain of chirps it replies
+// to (root first) and a page of everything below it, oldest first.
+func (cfg *apiConfig) handleGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respondWithError(w, 400, "You must enter a valid UUID")
+		return
+	}
+	page, err := parsePageQuery(r)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	chirp, err := cfg.db.GetVisibleChirp(r.Context(), database.GetVisibleChirpParams{ID: id, ViewerID: viewerID(r.Context())})
+	if err != nil {
+		respondWithError(w, 404, fmt.Sprintf("Chirp with id %s does not exist", id))
+		return
+	}
+	ancestors, err := cfg.db.ListChirpAncestors(r.Context(), database.ListChirpAncestorsParams{
+		ID:       id,
+		ViewerID: viewerID(r.Context()),
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching the thread: %s", err))
+		return
+	}
+	replies, err := cfg.db.ListChirpReplies(r.Context(), database.ListChirpRepliesParams{
+		ChirpID:         id,
+		ViewerID:        viewerID(r.Context()),
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		RowLimit:        page.rowLimit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching the thread: %s", err))
+		return
+	}
+
+	var resp chirpThread
+	if len(replies) > page.Limit {
+		replies = replies[:page.Limit]
+		last := replies[len(replies)-1]
+		resp.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+		setNextLink(w, r, resp.NextCursor, page.Limit)
+	}
+	all := append(append([]database.Chirp{chirp}, ancestors...), replies...)
+	rendered, err := cfg.renderChirps(r.Context(), all)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching the thread: %s", err))
+		return
+	}
+	resp.Chirp = rendered[0]
+	resp.Ancestors = rendered[1 : 1+len(ancestors)]
+	resp.Replies, err = cfg.applyMutedWords(r.Context(), rendered[1+len(ancestors):])
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching the thread: %s", err))
+		return
+	}
+	writeResponse(w, 200, resp)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"gitea.rannes.dev/christian/chirpy/internal/auth"
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+)
+
+const (
+	timelinePruneInterval = 1 * time.Hour
+	// timelineRetention is how deep a timeline can be paged; older entries
+	// are pruned so the table stays proportional to the number of users.
+	timelineRetention = 800
+)
+
+// runTimelineWorker periodically trims every timeline to its newest
+// timelineRetention entries.
+func (cfg *apiConfig) runTimelineWorker(ctx context.Context) {
+	ticker := time.NewTicker(timelinePruneInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := cfg.db.PruneTimelines(ctx, timelineRetention); err != nil {
+			log.Printf("Error pruning timelines: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// handleGetTimeline returns the caller's own chirps and those of everyone
+// they follow, newest first.
+func (cfg *apiConfig) handleGetTimeline(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	page, err := parsePageQuery(r)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	cfg.writeChirpPage(w, r, page, func(ctx context.Context, page pageQuery) ([]database.Chirp, error) {
+		return cfg.db.ListTimeline(ctx, database.ListTimelineParams{
+			UserID:          userId,
+			CursorCreatedAt: page.CursorCreatedAt,
+			CursorID:        page.CursorID,
+			RowLimit:        page.rowLimit(),
+		})
+	})
+}
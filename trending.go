@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gitea.rannes.dev/christian/chirpy/internal/database"
+	"gitea.rannes.dev/christian/chirpy/internal/trending"
+)
+
+const (
+	trendingInterval = 1 * time.Minute
+	trendingLimit    = 10
+)
+
+var trendingWindows = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+}
+
+type jsonTrendingHashtag struct {
+	Tag           string  `json:"tag"`
+	RecentCount   int64   `json:"recent_count"`
+	PreviousCount int64   `json:"previous_count"`
+	Score         float64 `json:"score"`
+}
+
+type jsonTrending struct {
+	Window     string                `json:"window"`
+	ComputedAt *time.Time            `json:"computed_at"`
+	Hashtags   []jsonTrendingHashtag `json:"hashtags"`
+}
+
+// runTrendingWorker periodically materializes the trending hashtags for each
+// window so GET /api/trending only has to read a handful of rows.
+func (cfg *apiConfig) runTrendingWorker(ctx context.Context) {
+	ticker := time.NewTicker(trendingInterval)
+	defer ticker.Stop()
+	for {
+		for name, window := range trendingWindows {
+			if err := cfg.computeTrending(ctx, name, window); err != nil {
+				log.Printf("Error computing trending hashtags for %s: %s", name, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) computeTrending(ctx context.Context, name string, window time.Duration) error {
+	rows, err := cfg.db.CountHashtagUsage(ctx, window.Seconds())
+	if err != nil {
+		return err
+	}
+	counts := []trending.Count{}
+	for _, row := range rows {
+		if row.RecentCount == 0 {
+			continue
+		}
+		counts = append(counts, trending.Count{
+			Tag:      row.Tag,
+			Recent:   row.RecentCount,
+			Previous: row.PreviousCount,
+		})
+	}
+
+	tx, err := cfg.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+	if err := qtx.DeleteTrendingHashtags(ctx, name); err != nil {
+		return err
+	}
+	for i, c := range trending.Rank(counts, trendingLimit) {
+		err := qtx.InsertTrendingHashtag(ctx, database.InsertTrendingHashtagParams{
+			WindowName:    name,
+			Rank:          int32(i + 1),
+			Tag:           c.Tag,
+			RecentCount:   c.Recent,
+			PreviousCount: c.Previous,
+			Score:         c.Score,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (cfg *apiConfig) handleGetTrending(w http.ResponseWriter, r *http.Request) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "hour"
+	}
+	if _, ok := trendingWindows[window]; !ok {
+		respondWithError(w, 400, "window must be hour or day")
+		return
+	}
+	rows, err := cfg.db.ListTrendingHashtags(r.Context(), window)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("There was an error fetching trending hashtags: %s", err))
+		return
+	}
+	resp := jsonTrending{Window: window, Hashtags: []jsonTrendingHashtag{}}
+	for _, row := range rows {
+		resp.ComputedAt = &row.ComputedAt
+		resp.Hashtags = append(resp.Hashtags, jsonTrendingHashtag{
+			Tag:           row.Tag,
+			RecentCount:   row.RecentCount,
+			PreviousCount: row.PreviousCount,
+			Score:         row.Score,
+		})
+	}
+	writeResponse(w, 200, resp)
+}
@@ -1,20 +1,29 @@
 package main
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
 
 	"gitea.rannes.dev/christian/chirpy/internal/auth"
+	"gitea.rannes.dev/christian/chirpy/internal/chirptext"
 	"gitea.rannes.dev/christian/chirpy/internal/database"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type PostUser struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Handle   string `json:"handle"`
 }
 
 type JsonUser struct {
@@ -22,10 +31,21 @@ type JsonUser struct {
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	Email        string    `json:"email"`
+	Handle       string    `json:"handle"`
 	Token        string    `json:"token"`
 	RefreshToken string    `json:"refresh_token"`
 }
 
+func toJsonUser(user database.User) JsonUser {
+	return JsonUser{
+		ID:        user.ID,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Email:     user.Email,
+		Handle:    user.Handle.String,
+	}
+}
+
 func (cfg *apiConfig) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 
 	decoder := json.NewDecoder(r.Body)
@@ -35,6 +55,14 @@ func (cfg *apiConfig) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 		log.Printf("There was an error decoding the request body: %s", err)
 		return
 	}
+	var handle sql.NullString
+	if userData.Handle != "" {
+		if err := chirptext.ValidateHandle(userData.Handle); err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		handle = sql.NullString{String: userData.Handle, Valid: true}
+	}
 	hashed, err := auth.HashPassword(userData.Password)
 	if err != nil {
 		respondWithError(w, 500, "There was an error hashing your password")
@@ -43,17 +71,17 @@ func (cfg *apiConfig) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
 		Email:          userData.Email,
 		HashedPassword: hashed,
+		Handle:         handle,
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "Email or handle is already in use")
+		return
+	}
 	if err != nil {
 		log.Printf("Error creating user: %s", err)
 		return
 	}
-	payload := JsonUser{
-		ID:        user.ID,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Email:     user.Email,
-	}
+	payload := toJsonUser(user)
 	response, err := json.Marshal(payload)
 	if err != nil {
 		log.Printf("Error encoding json: %s", err)
@@ -65,6 +93,163 @@ func (cfg *apiConfig) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+func (cfg *apiConfig) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
+	type userUpdate struct {
+		Email           string  `json:"email"`
+		Password        string  `json:"password"`
+		Handle          *string `json:"handle"`
+		CurrentPassword string  `json:"current_password"`
+	}
+	userId, _ := auth.UserIDFromContext(r.Context())
+	var data userUpdate
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		respondWithError(w, 400, "Error decoding request body")
+		return
+	}
+	user, err := cfg.db.GetUserByID(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 404, "User does not exist")
+		return
+	}
+	if data.Email == "" && data.Password == "" && data.Handle == nil {
+		respondWithError(w, 400, "Nothing to update")
+		return
+	}
+	// Changing the handle is not sensitive, email and password changes are.
+	if data.Email != "" || data.Password != "" {
+		if err := auth.CheckPasswordHash(data.CurrentPassword, user.HashedPassword); err != nil {
+			respondWithError(w, 403, "Current password is incorrect")
+			return
+		}
+	}
+
+	params := database.UpdateUserParams{
+		ID:             user.ID,
+		Email:          user.Email,
+		HashedPassword: user.HashedPassword,
+		Handle:         user.Handle,
+	}
+	if data.Handle != nil {
+		params.Handle = sql.NullString{}
+		if *data.Handle != "" {
+			if err := chirptext.ValidateHandle(*data.Handle); err != nil {
+				respondWithError(w, 400, err.Error())
+				return
+			}
+			params.Handle = sql.NullString{String: *data.Handle, Valid: true}
+		}
+	}
+	if data.Email != "" {
+		params.Email = data.Email
+	}
+	if data.Password != "" {
+		params.HashedPassword, err = auth.HashPassword(data.Password)
+		if err != nil {
+			respondWithError(w, 500, "There was an error hashing your password")
+			return
+		}
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "There was an error updating your account")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+	updated, err := qtx.UpdateUser(r.Context(), params)
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "Email or handle is already in use")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "There was an error updating your account")
+		return
+	}
+	payload := toJsonUser(updated)
+	// A password change signs out every device. The caller gets a fresh
+	// session so only the other devices have to log in again.
+	if data.Password != "" {
+		if _, err := qtx.RevokeUserRefreshTokens(r.Context(), user.ID); err != nil {
+			respondWithError(w, 500, "There was an error revoking your sessions")
+			return
+		}
+		payload.RefreshToken, err = cfg.startSession(r.Context(), qtx, r, user.ID, "")
+		if err != nil {
+			respondWithError(w, 500, "error creating refresh_token")
+			return
+		}
+		payload.Token, err = cfg.keys.MakeJWT(user.ID, cfg.jwtAudience, cfg.tokenExpiry)
+		if err != nil {
+			respondWithError(w, 500, fmt.Sprintf("error creating token: %v", err))
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, 500, "There was an error updating your account")
+		return
+	}
+	writeResponse(w, 200, payload)
+}
+
+func (cfg *apiConfig) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	type deleteConfirmation struct {
+		Password string `json:"password"`
+	}
+	userId, _ := auth.UserIDFromContext(r.Context())
+	var data deleteConfirmation
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		respondWithError(w, 400, "Error decoding request body")
+		return
+	}
+	user, err := cfg.db.GetUserByID(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 404, "User does not exist")
+		return
+	}
+	if err := auth.CheckPasswordHash(data.Password, user.HashedPassword); err != nil {
+		respondWithError(w, 403, "Password is incorrect")
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "There was an error deleting your account")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+	// The tombstone keeps an HMAC of the user id. User ids are public, so a
+	// plain hash could be linked back to the person by anyone; with the key
+	// only the server can check whether a given id was deleted.
+	mac := hmac.New(sha256.New, cfg.tombstoneKey)
+	mac.Write([]byte(user.ID.String()))
+	err = qtx.InsertDeletedUser(r.Context(), database.InsertDeletedUserParams{
+		ID:       user.ID,
+		UserHash: hex.EncodeToString(mac.Sum(nil)),
+	})
+	if err != nil {
+		respondWithError(w, 500, "There was an error deleting your account")
+		return
+	}
+	// Chirps and refresh tokens are removed by ON DELETE CASCADE.
+	if _, err := qtx.DeleteUser(r.Context(), user.ID); err != nil {
+		respondWithError(w, 500, "There was an error deleting your account")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, 500, "There was an error deleting your account")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// isUniqueViolation reports whether err is a postgres unique constraint error.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 func (cfg *apiConfig) handleResetUsers(w http.ResponseWriter, r *http.Request) {
 	if cfg.platform != "dev" {
 		w.WriteHeader(403)
@@ -77,28 +262,138 @@ func (cfg *apiConfig) handleResetUsers(w http.ResponseWriter, r *http.Request) {
 	}
 	return
 }
- 
+
 func (cfg *apiConfig) handleRefreshToken(w http.ResponseWriter, r *http.Request) {
-  refresh, err := auth.GetBearerToken(r.Header)
-  if err != nil {
-    respondWithError(w, 401, "No refresh token in headers.")
-    return
-  }
-  selectRefresh, err := cfg.db.GetRefreshToken(r.Context(), refresh)
-  if err != nil {
-    respondWithError(w, 401, "No refresh token found in db.")
-    return
-  }
-  if !selectRefresh.RevokedAt.IsZero() {
-    respondWithError(w, 401, "Your refresh token has expired")
-    return
-  }
+	refresh, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, 401, "No refresh token in headers.")
+		return
+	}
+	selectRefresh, err := cfg.db.GetRefreshToken(r.Context(), refresh)
+	if err != nil {
+		respondWithError(w, 401, "No refresh token found in db.")
+		return
+	}
+	if selectRefresh.RevokedAt.Valid {
+		if selectRefresh.ReplacedBy.Valid {
+			cfg.revokeTokenFamily(r.Context(), selectRefresh)
+			respondWithError(w, 401, "Refresh token reuse detected, please log in again")
+			return
+		}
+		respondWithError(w, 401, "Your refresh token has been revoked")
+		return
+	}
+	if time.Now().After(selectRefresh.ExpiresAt) {
+		respondWithError(w, 401, "Your refresh token has expired")
+		return
+	}
+
+	newRefresh, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, 500, "error creating refresh_token")
+		return
+	}
+	tx, err := cfg.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "There was an error rotating your refresh token")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+	rotated, err := qtx.RotateRefreshToken(r.Context(), database.RotateRefreshTokenParams{
+		Token:      selectRefresh.Token,
+		ReplacedBy: sql.NullString{String: newRefresh, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, 500, "There was an error rotating your refresh token")
+		return
+	}
+	if rotated == 0 {
+		// Another request rotated this token between our read and write.
+		tx.Rollback()
+		cfg.revokeTokenFamily(r.Context(), selectRefresh)
+		respondWithError(w, 401, "Refresh token reuse detected, please log in again")
+		return
+	}
+	err = qtx.InsertRefreshToken(r.Context(), database.InsertRefreshTokenParams{
+		Token:     newRefresh,
+		UserID:    selectRefresh.UserID,
+		ExpiresAt: time.Now().Add(cfg.resetExpiry),
+		FamilyID:  selectRefresh.FamilyID,
+		UserAgent: r.UserAgent(),
+		IpAddress: clientIP(r),
+		Label:     selectRefresh.Label,
+	})
+	if err != nil {
+		respondWithError(w, 500, "There was an error rotating your refresh token")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, 500, "There was an error rotating your refresh token")
+		return
+	}
+
+	token, err := cfg.keys.MakeJWT(selectRefresh.UserID, cfg.jwtAudience, cfg.tokenExpiry)
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("error creating token: %v", err))
+		return
+	}
+	type refreshResponse struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	writeResponse(w, 200, refreshResponse{
+		Token:        token,
+		RefreshToken: newRefresh,
+	})
+}
+
+func (cfg *apiConfig) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	refresh, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, 401, "No refresh token in headers.")
+		return
+	}
+	revoked, err := cfg.db.RevokeRefreshToken(r.Context(), refresh)
+	if err != nil {
+		respondWithError(w, 500, "There was an error revoking your refresh token")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, 401, "No active refresh token found in db.")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handleLogoutAll(w http.ResponseWriter, r *http.Request) {
+	userId, _ := auth.UserIDFromContext(r.Context())
+	_, err := cfg.db.RevokeUserRefreshTokens(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "There was an error revoking your sessions")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// revokeTokenFamily revokes every refresh token descended from the same login
+// as rt. It is called when an already rotated token is presented again, which
+// means the token chain has leaked.
+func (cfg *apiConfig) revokeTokenFamily(ctx context.Context, rt database.RefreshToken) {
+	_, err := cfg.db.RevokeRefreshTokenFamily(ctx, database.RevokeRefreshTokenFamilyParams{
+		FamilyID: rt.FamilyID,
+		UserID:   rt.UserID,
+	})
+	if err != nil {
+		log.Printf("Error revoking refresh token family %s: %s", rt.FamilyID, err)
+	}
 }
 
 func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 	type login struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email      string `json:"email"`
+		Password   string `json:"password"`
+		DeviceName string `json:"device_name"`
 	}
 	var data login
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
@@ -115,31 +410,19 @@ func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, 401, "Incorrect email or password")
 		return
 	}
-	fmt.Print(data)
-	token, err := auth.MakeJWT(user.ID, cfg.secret, time.Duration(cfg.tokenExpiry))
+	token, err := cfg.keys.MakeJWT(user.ID, cfg.jwtAudience, cfg.tokenExpiry)
 	if err != nil {
 		respondWithError(w, 400, fmt.Sprintf("error creating token: %v", err))
 		return
 	}
-  refresh, err := auth.MakeRefreshToken()
-  err = cfg.db.InsertRefreshToken(r.Context(), database.InsertRefreshTokenParams{
-    Token: refresh,
-    UserID: user.ID,
-    ExpiresAt: time.Now().Add(cfg.resetExpiry),
-    RevokedAt: time.Time{},
-  })
+	refresh, err := cfg.startSession(r.Context(), cfg.db, r, user.ID, data.DeviceName)
 	if err != nil {
 		respondWithError(w, 400, fmt.Sprintf("error creating refresh_token: %v", err))
 		return
 	}
-	returnUser := JsonUser{
-		ID:        user.ID,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Email:     user.Email,
-		Token:     token,
-    RefreshToken: refresh,
-	}
+	returnUser := toJsonUser(user)
+	returnUser.Token = token
+	returnUser.RefreshToken = refresh
 	writeResponse(w, 200, returnUser)
 	return
 }